@@ -0,0 +1,152 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+// Decrypter is our own representation of a private key used for decryption.
+// Like Recipient, it does not export any underlying crypto-library's type.
+type Decrypter struct {
+	protonKey *protonpgp.Key
+}
+
+// GetKeyID returns the key ID of the decryption key, hex encoded as a string.
+func (d *Decrypter) GetKeyID() string {
+	return d.protonKey.GetHexKeyID()
+}
+
+// GetDecrypter decodes and parses an armor-encoded private key, and unlocks it
+// with the passphrase if the key is protected. The passphrase is ignored for
+// keys which are not protected.
+func GetDecrypter(privateKey string, passphrase string) (*Decrypter, error) {
+	key, err := getPrivateKey(privateKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Decrypter{protonKey: key}, nil
+}
+
+// Decryption is the result of decrypting a message.
+type Decryption struct {
+	// Content is the decrypted message.
+	Content []byte
+	// SignerKeyID is the key ID of the key which signed the message, hex
+	// encoded. It is empty, if the message was not signed.
+	SignerKeyID string
+	// Verification is the result of verifying the signature against the
+	// trusted keys. It is nil, if no trusted keys were given.
+	Verification *Verification
+}
+
+// DecryptionOptions configures DecryptMessageWithOptions.
+type DecryptionOptions struct {
+	// Decrypter is the key, which the message was encrypted to.
+	Decrypter *Decrypter
+	// Passphrase is the passphrase, which the message was encrypted with.
+	// Either Decrypter or Passphrase must be given.
+	Passphrase string
+	// Trusted are the keys, which the signature of the message is verified
+	// against, if any are given.
+	Trusted []*Recipient
+	// Format is the encoding of the message, one of the output formats of
+	// EncryptionOptions. Empty means the Armor-encoding.
+	Format string
+}
+
+// DecryptMessage decrypts the armor-encoded message with the key of the
+// decrypter. If trusted keys are given, the signature of the message is
+// verified against them. An invalid signature is reported in the returned
+// Decryption, and is not an error.
+func DecryptMessage(decrypter *Decrypter, trusted []*Recipient, message string) (*Decryption, error) {
+	if decrypter == nil {
+		return nil, fmt.Errorf("no decrypter")
+	}
+
+	return DecryptMessageWithOptions(message, DecryptionOptions{Decrypter: decrypter, Trusted: trusted})
+}
+
+// DecryptMessageWithOptions decrypts the message with the key of the
+// decrypter, or with the passphrase given in options, like DecryptMessage.
+func DecryptMessageWithOptions(message string, options DecryptionOptions) (*Decryption, error) {
+	if options.Decrypter == nil && options.Passphrase == "" {
+		return nil, fmt.Errorf("no decrypter nor passphrase")
+	}
+
+	var data []byte
+	var encoding int8
+
+	switch options.Format {
+	case "", OutputFormatArmored:
+		data, encoding = []byte(message), protonpgp.Armor
+	case OutputFormatBinaryBase64:
+		decoded, err := base64.StdEncoding.DecodeString(message)
+		if err != nil {
+			return nil, fmt.Errorf("decoding binary message: %w", err)
+		}
+
+		data, encoding = decoded, protonpgp.Bytes
+	default:
+		return nil, fmt.Errorf("unsupported format %q", options.Format)
+	}
+
+	builder := protonpgp.PGP().Decryption()
+
+	if options.Decrypter != nil {
+		builder = builder.DecryptionKey(options.Decrypter.protonKey)
+	}
+
+	if options.Passphrase != "" {
+		builder = builder.Password([]byte(options.Passphrase))
+	}
+
+	trusted := options.Trusted
+
+	if len(trusted) > 0 {
+		keyring := &protonpgp.KeyRing{}
+		for i, v := range trusted {
+			if err := keyring.AddKey(v.protonKey); err != nil {
+				return nil, fmt.Errorf("adding key to keyring (index %d): %w", i, err)
+			}
+		}
+		builder = builder.VerificationKeys(keyring)
+	}
+
+	handle, err := builder.New()
+	if err != nil {
+		return nil, fmt.Errorf("creating decrypter: %w", err)
+	}
+
+	result, err := handle.Decrypt(data, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting message: %w", err)
+	}
+
+	decryption := &Decryption{
+		Content: result.Bytes(),
+	}
+
+	for _, signature := range result.Signatures {
+		if signature.Signature == nil {
+			continue
+		}
+		if signature.Signature.IssuerKeyId != nil {
+			decryption.SignerKeyID = fmt.Sprintf("%016x", *signature.Signature.IssuerKeyId)
+			break
+		}
+		// Signatures made by version 6 keys only have the fingerprint of the issuer.
+		if fingerprint := signature.Signature.IssuerFingerprint; len(fingerprint) >= 8 {
+			decryption.SignerKeyID = fmt.Sprintf("%x", fingerprint[:8])
+			break
+		}
+	}
+
+	if len(trusted) > 0 {
+		decryption.Verification = newVerification(trusted, &result.VerifyResult, decryption.Content)
+	}
+
+	return decryption, nil
+}
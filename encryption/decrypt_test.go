@@ -0,0 +1,165 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecryptMessage(t *testing.T) {
+	rsaRecipient, err := GetRecipient(publicKeyRSASigner)
+	require.NoError(t, err)
+	curveSigner, err := GetSigner(privateKeyCurve, "")
+	require.NoError(t, err)
+
+	message := "hello world"
+	unsigned, err := EncryptAndEncodeMessage([]*Recipient{rsaRecipient}, message)
+	require.NoError(t, err)
+	signed, err := EncryptSignAndEncodeMessage([]*Recipient{rsaRecipient}, curveSigner, message)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name                string
+		message             string
+		trustedKeys         []string
+		expectedSignerKeyID string
+		expectVerification  bool
+		expectValid         bool
+	}{
+		{name: "unsigned", message: unsigned},
+		{name: "unsigned, with trusted keys", message: unsigned, trustedKeys: []string{publicKeyCurveSigner}, expectVerification: true},
+		{name: "signed, without trusted keys", message: signed, expectedSignerKeyID: "75fac23672df26de"},
+		{name: "signed by trusted key", message: signed, trustedKeys: []string{publicKeyCurveSigner}, expectedSignerKeyID: "75fac23672df26de", expectVerification: true, expectValid: true},
+		{name: "signed by untrusted key", message: signed, trustedKeys: []string{publicKeyCurve}, expectedSignerKeyID: "75fac23672df26de", expectVerification: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decrypter, err := GetDecrypter(privateKeyRSA, privateKeyRSAPassphrase)
+			require.NoError(t, err)
+			trusted, err := GetRecipients(tc.trustedKeys)
+			require.NoError(t, err)
+
+			decryption, err := DecryptMessage(decrypter, trusted, tc.message)
+			require.NoError(t, err)
+			assert.Equal(t, message, string(decryption.Content))
+			assert.Equal(t, tc.expectedSignerKeyID, decryption.SignerKeyID)
+
+			if !tc.expectVerification {
+				assert.Nil(t, decryption.Verification)
+				return
+			}
+			require.NotNil(t, decryption.Verification)
+			assert.Equal(t, tc.expectValid, decryption.Verification.IsValid())
+			if tc.expectValid {
+				assert.Equal(t, "75fac23672df26de", decryption.Verification.Signer.GetKeyID())
+			}
+		})
+	}
+}
+
+func TestDecryptMessageFailure(t *testing.T) {
+	curveRecipient, err := GetRecipient(publicKeyCurveSigner)
+	require.NoError(t, err)
+	encrypted, err := EncryptAndEncodeMessage([]*Recipient{curveRecipient}, "hello world")
+	require.NoError(t, err)
+
+	decrypter, err := GetDecrypter(privateKeyRSA, privateKeyRSAPassphrase)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		decrypter     *Decrypter
+		message       string
+		expectedError string
+	}{
+		{name: "no decrypter", message: encrypted, expectedError: "no decrypter"},
+		{name: "wrong key", decrypter: decrypter, message: encrypted, expectedError: "decrypting message"},
+		{name: "malformed message", decrypter: decrypter, message: "not a message", expectedError: "decrypting message"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decryption, err := DecryptMessage(tc.decrypter, nil, tc.message)
+			require.ErrorContains(t, err, tc.expectedError)
+			assert.Nil(t, decryption)
+		})
+	}
+}
+
+func TestDecryptMessageWithOptions(t *testing.T) {
+	rsaRecipient, err := GetRecipient(publicKeyRSASigner)
+	require.NoError(t, err)
+	decrypter, err := GetDecrypter(privateKeyRSA, privateKeyRSAPassphrase)
+	require.NoError(t, err)
+
+	message := "hello world"
+
+	testCases := []struct {
+		name              string
+		recipients        []*Recipient
+		encryptionOptions EncryptionOptions
+		options           DecryptionOptions
+	}{
+		{
+			name:              "binary message",
+			recipients:        []*Recipient{rsaRecipient},
+			encryptionOptions: EncryptionOptions{OutputFormat: OutputFormatBinaryBase64},
+			options:           DecryptionOptions{Decrypter: decrypter, Format: OutputFormatBinaryBase64},
+		},
+		{
+			name:              "passphrase",
+			encryptionOptions: EncryptionOptions{Passphrase: "passphrase"},
+			options:           DecryptionOptions{Passphrase: "passphrase"},
+		},
+		{
+			name:              "binary message with passphrase",
+			encryptionOptions: EncryptionOptions{Passphrase: "passphrase", OutputFormat: OutputFormatBinaryBase64},
+			options:           DecryptionOptions{Passphrase: "passphrase", Format: OutputFormatBinaryBase64},
+		},
+		{
+			name:              "passphrase of message with recipients",
+			recipients:        []*Recipient{rsaRecipient},
+			encryptionOptions: EncryptionOptions{Passphrase: "passphrase"},
+			options:           DecryptionOptions{Passphrase: "passphrase"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encrypted, err := EncryptAndEncodeMessageWithOptions(tc.recipients, strings.NewReader(message), tc.encryptionOptions)
+			require.NoError(t, err)
+
+			decryption, err := DecryptMessageWithOptions(encrypted, tc.options)
+			require.NoError(t, err)
+			assert.Equal(t, message, string(decryption.Content))
+		})
+	}
+}
+
+func TestDecryptMessageWithOptionsFailure(t *testing.T) {
+	encrypted, err := EncryptAndEncodeMessageWithOptions(nil, strings.NewReader("hello world"), EncryptionOptions{Passphrase: "passphrase"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		message       string
+		options       DecryptionOptions
+		expectedError string
+	}{
+		{name: "no decrypter nor passphrase", message: encrypted, expectedError: "no decrypter nor passphrase"},
+		{name: "wrong passphrase", message: encrypted, options: DecryptionOptions{Passphrase: "wrong"}, expectedError: "decrypting message"},
+		{name: "armored message as binary", message: encrypted, options: DecryptionOptions{Passphrase: "passphrase", Format: OutputFormatBinaryBase64}, expectedError: "decoding binary message"},
+		{name: "unsupported format", message: encrypted, options: DecryptionOptions{Passphrase: "passphrase", Format: "hex"}, expectedError: `unsupported format "hex"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decryption, err := DecryptMessageWithOptions(tc.message, tc.options)
+			require.ErrorContains(t, err, tc.expectedError)
+			assert.Nil(t, decryption)
+		})
+	}
+}
@@ -2,12 +2,17 @@ package encryption
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"strings"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/ProtonMail/go-crypto/openpgp/s2k"
 	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/ProtonMail/gopenpgp/v3/profile"
 )
 
 // Recipient is our own representation of a recipient/key.
@@ -21,6 +26,11 @@ func (r *Recipient) GetKeyID() string {
 	return r.protonKey.GetHexKeyID()
 }
 
+// GetFingerprint returns the fingerprint of the primary key, hex encoded as a string.
+func (r *Recipient) GetFingerprint() string {
+	return r.protonKey.GetFingerprint()
+}
+
 // IsExpired returns whether the key is expired at the given point in time.
 func (r *Recipient) IsExpired(t time.Time) bool {
 	return r.protonKey.IsExpired(t.UTC().Unix())
@@ -36,6 +46,13 @@ func (r *Recipient) GetUserEmail(t time.Time) (string, bool) {
 	return id.UserId.Email, true
 }
 
+// supportsAEAD returns whether the key advertises support for AEAD encryption
+// (SEIPDv2 from RFC 9580) at the given point in time.
+func (r *Recipient) supportsAEAD(t time.Time) bool {
+	selfSig, err := r.protonKey.GetEntity().PrimarySelfSignature(t, nil)
+	return err == nil && selfSig.SEIPDv2
+}
+
 // GetRecipients decodes and parses a list of armor-encoded public keys.
 func GetRecipients(publicKeys []string) ([]*Recipient, error) {
 	// Store recipients for encryption.
@@ -63,39 +80,305 @@ func GetRecipient(publicKey string) (*Recipient, error) {
 	return &Recipient{protonKey: key}, nil
 }
 
+// Supported S2K modes, which derive the key from the passphrase in passphrase encryption.
+const (
+	// S2KModeIterated is the iterated and salted S2K from RFC 4880, which is supported by all OpenPGP implementations.
+	S2KModeIterated = "iterated"
+	// S2KModeArgon2 is the memory-hard Argon2 S2K from RFC 9580.
+	S2KModeArgon2 = "argon2"
+)
+
+// S2KModes returns all the S2K modes supported by EncryptionOptions.
+func S2KModes() []string {
+	return []string{S2KModeIterated, S2KModeArgon2}
+}
+
+// Supported output formats of encrypted messages.
+const (
+	// OutputFormatArmored is the ASCII-armored message.
+	OutputFormatArmored = "armored"
+	// OutputFormatBinaryBase64 is the binary (unarmored) message, encoded in
+	// single-line standard base64.
+	OutputFormatBinaryBase64 = "binary_base64"
+)
+
+// OutputFormats returns all the output formats supported by EncryptionOptions.
+func OutputFormats() []string {
+	return []string{OutputFormatArmored, OutputFormatBinaryBase64}
+}
+
+// Supported profiles, which select the algorithms and packet versions of encrypted messages.
+const (
+	// ProfileDefault uses algorithms, which are widely implemented.
+	ProfileDefault = "default"
+	// ProfileRFC4880 conforms to RFC 4880, for compatibility with legacy implementations.
+	ProfileRFC4880 = "rfc4880"
+	// ProfileRFC9580 conforms to RFC 9580, and uses AEAD encryption and Argon2 S2K,
+	// wherever all of the recipients support it.
+	ProfileRFC9580 = "rfc9580"
+)
+
+// Profiles returns all the profiles supported by EncryptionOptions.
+func Profiles() []string {
+	return []string{ProfileDefault, ProfileRFC4880, ProfileRFC9580}
+}
+
+// EncryptionOptions are optional settings for encrypting messages.
+type EncryptionOptions struct {
+	// Signer signs the message, if set.
+	Signer *Signer
+	// Passphrase encrypts the message symmetrically, in addition to the recipients, if set.
+	Passphrase string
+	// S2KMode is one of the S2KMode constants. Defaults to S2KModeArgon2 in
+	// ProfileRFC9580, and to S2KModeIterated otherwise.
+	S2KMode string
+	// Profile is one of the Profile constants. Defaults to ProfileDefault.
+	Profile string
+	// Time is used instead of the current time to check validity of the keys,
+	// and as the time of encryption and signature, if set.
+	Time time.Time
+	// OutputFormat is one of the OutputFormat constants. Defaults to OutputFormatArmored.
+	OutputFormat string
+}
+
 // EncryptAndEncodeMessage encrypts the message to all of the recipients.
 // The message is encoded in the Armor-encoding.
 func EncryptAndEncodeMessage(recipients []*Recipient, message string) (string, error) {
-	if len(recipients) == 0 {
-		return "", fmt.Errorf("no recipients")
+	return EncryptAndEncodeMessageWithOptions(recipients, strings.NewReader(message), EncryptionOptions{})
+}
+
+// EncryptSignAndEncodeMessage encrypts the message to all of the recipients,
+// and signs it with the key of the signer. The signature is encrypted together
+// with the message, so only the recipients can verify it.
+// The message is encoded in the Armor-encoding.
+func EncryptSignAndEncodeMessage(recipients []*Recipient, signer *Signer, message string) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("no signer")
 	}
 
-	keyring := &protonpgp.KeyRing{}
-	for i, v := range recipients {
-		err := keyring.AddKey(v.protonKey)
+	return EncryptAndEncodeMessageWithOptions(recipients, strings.NewReader(message), EncryptionOptions{Signer: signer})
+}
+
+// EncryptAndEncodeMessageWithOptions encrypts the message to all of the
+// recipients, and to the passphrase, if one is given in options. The whole
+// encrypted message is buffered in memory, see Encrypt for streaming.
+func EncryptAndEncodeMessageWithOptions(recipients []*Recipient, message io.Reader, options EncryptionOptions) (string, error) {
+	buf := bytes.NewBuffer(nil)
+
+	if _, err := Encrypt(context.Background(), buf, message, recipients, options); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// EncryptionMetadata describes how a message was encrypted.
+type EncryptionMetadata struct {
+	// RecipientKeyIDs are the key IDs of the recipients, in the given order.
+	RecipientKeyIDs []string
+	// RecipientFingerprints are the fingerprints of the recipients, in the given order.
+	RecipientFingerprints []string
+	// Passphrase is whether the message can be decrypted with the passphrase.
+	Passphrase bool
+	// AEAD is whether the message is encrypted with AEAD (SEIPDv2 from RFC 9580).
+	AEAD bool
+	// SignerKeyID is the key ID of the signer, or empty, if the message is not signed.
+	SignerKeyID string
+	// PlaintextSize is the number of bytes read from the source.
+	PlaintextSize int64
+	// CiphertextSize is the number of bytes written to the destination.
+	CiphertextSize int64
+}
+
+// Encrypt encrypts the message streamed from src to all of the recipients, and
+// to the passphrase, if one is given in options, and streams the encrypted
+// message to dst. The message can be decrypted with any of the recipients'
+// keys, or with the passphrase. It is encrypted as binary literal data, so
+// arbitrary bytes are preserved as they are, and encoded according to the
+// output format in options, which defaults to the Armor-encoding.
+// Encryption is aborted, when the context is cancelled.
+func Encrypt(ctx context.Context, dst io.Writer, src io.Reader, recipients []*Recipient, options EncryptionOptions) (*EncryptionMetadata, error) {
+	if len(recipients) == 0 && options.Passphrase == "" {
+		return nil, fmt.Errorf("no recipients")
+	}
+
+	encryptionProfile, err := newEncryptionProfile(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoding int8
+
+	switch options.OutputFormat {
+	case "", OutputFormatArmored:
+		encoding = protonpgp.Armor
+	case OutputFormatBinaryBase64:
+		encoding = protonpgp.Bytes
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", options.OutputFormat)
+	}
+
+	metadata := &EncryptionMetadata{
+		RecipientKeyIDs:       make([]string, 0, len(recipients)),
+		RecipientFingerprints: make([]string, 0, len(recipients)),
+		Passphrase:            options.Passphrase != "",
+		AEAD:                  encryptionProfile.AeadEncryption != nil,
+	}
+
+	now := options.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Argon2 is only explicitly requested, or implied by the profile for passphrases.
+	argon2 := options.S2KMode == S2KModeArgon2 ||
+		(options.S2KMode == "" && isRFC9580Profile(options.Profile) && options.Passphrase != "")
+
+	for _, recipient := range recipients {
+		supportsAEAD := recipient.supportsAEAD(now)
+
+		// Without AEAD support from all recipients, the message would fall back to
+		// non-AEAD encryption, which RFC 9580 does not allow to combine with Argon2.
+		if argon2 && !supportsAEAD {
+			return nil, fmt.Errorf("S2K mode %q requires AEAD encryption, which key %s does not support", S2KModeArgon2, recipient.GetKeyID())
+		}
+
+		metadata.RecipientKeyIDs = append(metadata.RecipientKeyIDs, recipient.GetKeyID())
+		metadata.RecipientFingerprints = append(metadata.RecipientFingerprints, recipient.GetFingerprint())
+		metadata.AEAD = metadata.AEAD && supportsAEAD
+	}
+
+	builder := protonpgp.PGPWithProfile(encryptionProfile).Encryption()
+
+	if !options.Time.IsZero() {
+		builder = builder.EncryptionTime(options.Time.Unix()).SignTime(options.Time.Unix())
+	}
+
+	if len(recipients) > 0 {
+		keyring := &protonpgp.KeyRing{}
+		for i, v := range recipients {
+			err := keyring.AddKey(v.protonKey)
+			if err != nil {
+				return nil, fmt.Errorf("adding key to keyring (index %d): %w", i, err)
+			}
+		}
+		builder = builder.Recipients(keyring)
+	}
+
+	if options.Passphrase != "" {
+		builder = builder.Password([]byte(options.Passphrase))
+	}
+
+	if options.Signer != nil {
+		signingKeyring, err := protonpgp.NewKeyRing(options.Signer.protonKey)
 		if err != nil {
-			return "", fmt.Errorf("adding key to keyring (index %d): %w", i, err)
+			return nil, fmt.Errorf("adding signing key to keyring: %w", err)
 		}
+		builder = builder.SigningKeys(signingKeyring)
+		metadata.SignerKeyID = options.Signer.GetKeyID()
 	}
 
-	encrypter, err := protonpgp.PGP().Encryption().Recipients(keyring).New()
+	encrypter, err := builder.New()
 	if err != nil {
-		return "", fmt.Errorf("creating encrypter: %w", err)
+		return nil, fmt.Errorf("creating encrypter: %w", err)
 	}
 
-	buf := bytes.NewBuffer(nil)
-	wcEncrypt, err := encrypter.EncryptingWriter(buf, protonpgp.Armor)
+	output := &countingWriter{writer: dst}
+
+	// Binary message is base64-encoded on the fly.
+	var encodedOutput io.Writer = output
+	var base64Encoder io.WriteCloser
+	if options.OutputFormat == OutputFormatBinaryBase64 {
+		base64Encoder = base64.NewEncoder(base64.StdEncoding, output)
+		encodedOutput = base64Encoder
+	}
+
+	wcEncrypt, err := encrypter.EncryptingWriter(encodedOutput, encoding)
 	if err != nil {
-		return "", fmt.Errorf("encrypting message: %w", err)
+		return nil, fmt.Errorf("encrypting message: %w", err)
 	}
 
-	if _, err := io.Copy(wcEncrypt, strings.NewReader(message)); err != nil {
-		return "", fmt.Errorf("writing content to buffer: %w", err)
+	metadata.PlaintextSize, err = io.Copy(wcEncrypt, &contextReader{ctx: ctx, reader: src})
+	if err != nil {
+		return nil, fmt.Errorf("writing content to encrypted message: %w", err)
 	}
 
 	if err := wcEncrypt.Close(); err != nil {
-		return "", fmt.Errorf("closing encrypted message: %w", err)
+		return nil, fmt.Errorf("closing encrypted message: %w", err)
 	}
 
-	return buf.String(), nil
+	if base64Encoder != nil {
+		if err := base64Encoder.Close(); err != nil {
+			return nil, fmt.Errorf("closing base64 encoder: %w", err)
+		}
+	}
+
+	metadata.CiphertextSize = output.written
+
+	return metadata, nil
+}
+
+// contextReader aborts reading, when the context is cancelled.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.reader.Read(p)
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+
+	return n, err
+}
+
+func newEncryptionProfile(options EncryptionOptions) (*profile.Custom, error) {
+	var encryptionProfile *profile.Custom
+
+	switch options.Profile {
+	case "", ProfileDefault:
+		encryptionProfile = profile.Default()
+	case ProfileRFC4880:
+		encryptionProfile = profile.RFC4880()
+	case ProfileRFC9580:
+		encryptionProfile = profile.RFC9580()
+	default:
+		return nil, fmt.Errorf("unsupported profile %q", options.Profile)
+	}
+
+	switch options.S2KMode {
+	case "":
+		// RFC 9580 profile already uses Argon2 S2K.
+		if !isRFC9580Profile(options.Profile) {
+			encryptionProfile.S2kEncryption = &s2k.Config{S2KMode: s2k.IteratedSaltedS2K}
+		}
+	case S2KModeIterated:
+		encryptionProfile.S2kEncryption = &s2k.Config{S2KMode: s2k.IteratedSaltedS2K}
+	case S2KModeArgon2:
+		// RFC 9580 only allows Argon2 together with AEAD encryption.
+		encryptionProfile.S2kEncryption = &s2k.Config{S2KMode: s2k.Argon2S2K}
+		encryptionProfile.AeadEncryption = &packet.AEADConfig{}
+	default:
+		return nil, fmt.Errorf("unsupported S2K mode %q", options.S2KMode)
+	}
+
+	return encryptionProfile, nil
+}
+
+// isRFC9580Profile returns whether the profile is based on RFC 9580.
+func isRFC9580Profile(name string) bool {
+	return name == ProfileRFC9580
 }
@@ -1,6 +1,11 @@
 package encryption
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -57,6 +62,363 @@ func TestEncryptMessageExpired(t *testing.T) {
 	}
 }
 
+func TestEncryptAndSignMessageSuccessful(t *testing.T) {
+	testCases := []struct {
+		name                string
+		recipientPrivateKey string
+		recipientPassphrase string
+		recipientPublicKey  string
+		signerPrivateKey    string
+		signerPassphrase    string
+		signerPublicKey     string
+	}{
+		{
+			name:                "encrypt to curve, sign with rsa",
+			recipientPrivateKey: privateKeyCurve,
+			recipientPublicKey:  publicKeyCurveSigner,
+			signerPrivateKey:    privateKeyRSA,
+			signerPassphrase:    privateKeyRSAPassphrase,
+			signerPublicKey:     publicKeyRSASigner,
+		},
+		{
+			name:                "encrypt to rsa, sign with curve",
+			recipientPrivateKey: privateKeyRSA,
+			recipientPassphrase: privateKeyRSAPassphrase,
+			recipientPublicKey:  publicKeyRSASigner,
+			signerPrivateKey:    privateKeyCurve,
+			signerPublicKey:     publicKeyCurveSigner,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipients, err := GetRecipients([]string{tc.recipientPublicKey})
+			require.NoError(t, err)
+			signer, err := GetSigner(tc.signerPrivateKey, tc.signerPassphrase)
+			require.NoError(t, err)
+			message := "hello world"
+			result, err := EncryptSignAndEncodeMessage(recipients, signer, message)
+			require.NoError(t, err)
+			assert.True(t, protonpgp.IsPGPMessage(result), "encrypted messages is not PGP message")
+
+			decryptionKey, err := protonpgp.NewPrivateKeyFromArmored(tc.recipientPrivateKey, []byte(tc.recipientPassphrase))
+			require.NoError(t, err)
+			verificationKey, err := protonpgp.NewKeyFromArmored(tc.signerPublicKey)
+			require.NoError(t, err)
+			decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(decryptionKey).VerificationKey(verificationKey).New()
+			require.NoError(t, err)
+			decrypted, err := decrypter.Decrypt([]byte(result), protonpgp.Armor)
+			require.NoError(t, err)
+			require.NoError(t, decrypted.SignatureError())
+			assert.Equal(t, message, decrypted.String())
+		})
+	}
+}
+
+func TestEncryptAndSignMessageNoSigner(t *testing.T) {
+	recipients, err := GetRecipients([]string{publicKeyCurve})
+	require.NoError(t, err)
+	result, err := EncryptSignAndEncodeMessage(recipients, nil, "hello world")
+	require.EqualError(t, err, "no signer")
+	assert.Empty(t, result)
+}
+
+func TestEncryptMessageWithPassphraseSuccessful(t *testing.T) {
+	testCases := []struct {
+		name       string
+		publicKeys []string
+		s2kMode    string
+	}{
+		{name: "passphrase only", s2kMode: S2KModeIterated},
+		{name: "passphrase only (default mode)"},
+		{name: "passphrase only (argon2)", s2kMode: S2KModeArgon2},
+		{name: "passphrase + rsa", publicKeys: []string{publicKeyRSA}, s2kMode: S2KModeIterated},
+		{name: "passphrase + rsa + curve", publicKeys: []string{publicKeyRSA, publicKeyCurve}, s2kMode: S2KModeIterated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipients, err := GetRecipients(tc.publicKeys)
+			require.NoError(t, err)
+			message := "hello world"
+			result, err := EncryptAndEncodeMessageWithOptions(recipients, strings.NewReader(message), EncryptionOptions{Passphrase: "passphrase", S2KMode: tc.s2kMode})
+			require.NoError(t, err)
+			assert.True(t, protonpgp.IsPGPMessage(result), "encrypted messages is not PGP message")
+
+			decrypter, err := protonpgp.PGP().Decryption().Password([]byte("passphrase")).New()
+			require.NoError(t, err)
+			decrypted, err := decrypter.Decrypt([]byte(result), protonpgp.Armor)
+			require.NoError(t, err)
+			assert.Equal(t, message, decrypted.String())
+		})
+	}
+}
+
+func TestEncryptMessageWithPassphraseAndRecipient(t *testing.T) {
+	recipients, err := GetRecipients([]string{publicKeyCurveSigner})
+	require.NoError(t, err)
+	message := "hello world"
+	result, err := EncryptAndEncodeMessageWithOptions(recipients, strings.NewReader(message), EncryptionOptions{Passphrase: "passphrase"})
+	require.NoError(t, err)
+
+	decryptionKey, err := protonpgp.NewKeyFromArmored(privateKeyCurve)
+	require.NoError(t, err)
+	decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(decryptionKey).New()
+	require.NoError(t, err)
+	decrypted, err := decrypter.Decrypt([]byte(result), protonpgp.Armor)
+	require.NoError(t, err)
+	assert.Equal(t, message, decrypted.String())
+}
+
+func TestEncryptBinaryMessage(t *testing.T) {
+	recipients, err := GetRecipients([]string{publicKeyCurveSigner})
+	require.NoError(t, err)
+	message := []byte{0x00, 0xff, 0xfe, '\r', '\n', 0x80, 0x0a}
+	result, err := EncryptAndEncodeMessageWithOptions(recipients, bytes.NewReader(message), EncryptionOptions{})
+	require.NoError(t, err)
+
+	decryptionKey, err := protonpgp.NewKeyFromArmored(privateKeyCurve)
+	require.NoError(t, err)
+	decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(decryptionKey).New()
+	require.NoError(t, err)
+	decrypted, err := decrypter.Decrypt([]byte(result), protonpgp.Armor)
+	require.NoError(t, err)
+	assert.Equal(t, message, decrypted.Bytes())
+	assert.False(t, decrypted.Metadata().IsUtf8(), "literal data is not binary")
+}
+
+func TestEncryptMessageOutputFormats(t *testing.T) {
+	testCases := []struct {
+		name         string
+		outputFormat string
+		decode       func(t *testing.T, result string) ([]byte, int8)
+	}{
+		{
+			name:         "armored",
+			outputFormat: OutputFormatArmored,
+			decode: func(t *testing.T, result string) ([]byte, int8) {
+				assert.True(t, protonpgp.IsPGPMessage(result), "encrypted messages is not PGP message")
+				return []byte(result), protonpgp.Armor
+			},
+		},
+		{
+			name: "default",
+			decode: func(t *testing.T, result string) ([]byte, int8) {
+				assert.True(t, protonpgp.IsPGPMessage(result), "encrypted messages is not PGP message")
+				return []byte(result), protonpgp.Armor
+			},
+		},
+		{
+			name:         "binary_base64",
+			outputFormat: OutputFormatBinaryBase64,
+			decode: func(t *testing.T, result string) ([]byte, int8) {
+				assert.NotContains(t, result, "\n")
+				decoded, err := base64.StdEncoding.DecodeString(result)
+				require.NoError(t, err)
+				return decoded, protonpgp.Bytes
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipients, err := GetRecipients([]string{publicKeyCurveSigner})
+			require.NoError(t, err)
+			message := "hello world"
+			result, err := EncryptAndEncodeMessageWithOptions(recipients, strings.NewReader(message), EncryptionOptions{OutputFormat: tc.outputFormat})
+			require.NoError(t, err)
+			encrypted, encoding := tc.decode(t, result)
+
+			decryptionKey, err := protonpgp.NewKeyFromArmored(privateKeyCurve)
+			require.NoError(t, err)
+			decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(decryptionKey).New()
+			require.NoError(t, err)
+			decrypted, err := decrypter.Decrypt(encrypted, encoding)
+			require.NoError(t, err)
+			assert.Equal(t, message, decrypted.String())
+		})
+	}
+}
+
+func TestEncryptMetadata(t *testing.T) {
+	testCases := []struct {
+		name             string
+		publicKeys       []string
+		options          func(t *testing.T) EncryptionOptions
+		expectedMetadata EncryptionMetadata
+	}{
+		{
+			name:       "recipients",
+			publicKeys: []string{publicKeyRSA, publicKeyCurve},
+			options:    func(*testing.T) EncryptionOptions { return EncryptionOptions{} },
+			expectedMetadata: EncryptionMetadata{
+				RecipientKeyIDs:       []string{"4f54663daabdbaff", "27076d92c444bc87"},
+				RecipientFingerprints: []string{"40b59cc2ed3da2213fd0aa5c4f54663daabdbaff", "f7a25236fede875f6308be6627076d92c444bc87"},
+			},
+		},
+		{
+			name:       "recipient, passphrase and signer",
+			publicKeys: []string{publicKeyCurve},
+			options: func(t *testing.T) EncryptionOptions {
+				signer, err := GetSigner(privateKeyCurve, "")
+				require.NoError(t, err)
+				return EncryptionOptions{Passphrase: "passphrase", Signer: signer, OutputFormat: OutputFormatBinaryBase64}
+			},
+			expectedMetadata: EncryptionMetadata{
+				RecipientKeyIDs:       []string{"27076d92c444bc87"},
+				RecipientFingerprints: []string{"f7a25236fede875f6308be6627076d92c444bc87"},
+				Passphrase:            true,
+				SignerKeyID:           "75fac23672df26de",
+			},
+		},
+		{
+			name: "passphrase (argon2)",
+			options: func(*testing.T) EncryptionOptions {
+				return EncryptionOptions{Passphrase: "passphrase", S2KMode: S2KModeArgon2}
+			},
+			expectedMetadata: EncryptionMetadata{
+				RecipientKeyIDs:       []string{},
+				RecipientFingerprints: []string{},
+				Passphrase:            true,
+				AEAD:                  true,
+			},
+		},
+		{
+			name: "passphrase (rfc9580 profile)",
+			options: func(*testing.T) EncryptionOptions {
+				return EncryptionOptions{Passphrase: "passphrase", Profile: ProfileRFC9580}
+			},
+			expectedMetadata: EncryptionMetadata{
+				RecipientKeyIDs:       []string{},
+				RecipientFingerprints: []string{},
+				Passphrase:            true,
+				AEAD:                  true,
+			},
+		},
+		{
+			name:       "recipient without AEAD support (rfc9580 profile)",
+			publicKeys: []string{publicKeyRSA},
+			options: func(*testing.T) EncryptionOptions {
+				return EncryptionOptions{Profile: ProfileRFC9580}
+			},
+			expectedMetadata: EncryptionMetadata{
+				RecipientKeyIDs:       []string{"4f54663daabdbaff"},
+				RecipientFingerprints: []string{"40b59cc2ed3da2213fd0aa5c4f54663daabdbaff"},
+			},
+		},
+		{
+			name:       "expired recipient (time before expiry)",
+			publicKeys: []string{publicKeyCurveExpired},
+			options: func(*testing.T) EncryptionOptions {
+				return EncryptionOptions{Profile: ProfileRFC4880, Time: time.Date(2024, 9, 25, 0, 0, 0, 0, time.UTC)}
+			},
+			expectedMetadata: EncryptionMetadata{
+				RecipientKeyIDs:       []string{"9edb3fd181a2ee9f"},
+				RecipientFingerprints: []string{"d8a9fe58bea659391452ce3d9edb3fd181a2ee9f"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipients, err := GetRecipients(tc.publicKeys)
+			require.NoError(t, err)
+			buf := bytes.NewBuffer(nil)
+			metadata, err := Encrypt(context.Background(), buf, strings.NewReader("hello world"), recipients, tc.options(t))
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(len("hello world")), metadata.PlaintextSize)
+			assert.Equal(t, int64(buf.Len()), metadata.CiphertextSize)
+			metadata.PlaintextSize, metadata.CiphertextSize = 0, 0
+			assert.Equal(t, tc.expectedMetadata, *metadata)
+		})
+	}
+}
+
+func TestEncryptMessageProfiles(t *testing.T) {
+	for _, profile := range Profiles() {
+		t.Run(profile, func(t *testing.T) {
+			recipients, err := GetRecipients([]string{publicKeyCurveSigner, publicKeyRSASigner})
+			require.NoError(t, err)
+			message := "hello world"
+			buf := bytes.NewBuffer(nil)
+			_, err = Encrypt(context.Background(), buf, strings.NewReader(message), recipients, EncryptionOptions{Profile: profile})
+			require.NoError(t, err)
+
+			// Every recipient must be able to decrypt the message.
+			privateKeys := []struct{ key, passphrase string }{
+				{key: privateKeyCurve},
+				{key: privateKeyRSA, passphrase: privateKeyRSAPassphrase},
+			}
+			for i, privateKey := range privateKeys {
+				decrypter, err := GetDecrypter(privateKey.key, privateKey.passphrase)
+				require.NoError(t, err, "private key #%d", i)
+				decryption, err := DecryptMessage(decrypter, nil, buf.String())
+				require.NoError(t, err, "private key #%d", i)
+				assert.Equal(t, message, string(decryption.Content), "private key #%d", i)
+			}
+		})
+	}
+}
+
+func TestEncryptCancelled(t *testing.T) {
+	recipients, err := GetRecipients([]string{publicKeyCurve})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	metadata, err := Encrypt(ctx, io.Discard, strings.NewReader("hello world"), recipients, EncryptionOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, metadata)
+}
+
+func TestEncryptMessageWithOptionsInvalid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		publicKeys    []string
+		options       EncryptionOptions
+		expectedError string
+	}{
+		{name: "no recipients and no passphrase", expectedError: "no recipients"},
+		{
+			name:          "unsupported s2k mode",
+			options:       EncryptionOptions{Passphrase: "passphrase", S2KMode: "simple"},
+			expectedError: `unsupported S2K mode "simple"`,
+		},
+		{
+			name:          "unsupported output format",
+			options:       EncryptionOptions{Passphrase: "passphrase", OutputFormat: "binary"},
+			expectedError: `unsupported output format "binary"`,
+		},
+		{
+			name:          "argon2 with recipient without AEAD support",
+			publicKeys:    []string{publicKeyRSA},
+			options:       EncryptionOptions{Passphrase: "passphrase", S2KMode: S2KModeArgon2},
+			expectedError: `S2K mode "argon2" requires AEAD encryption, which key 4f54663daabdbaff does not support`,
+		},
+		{
+			name:          "unsupported profile",
+			options:       EncryptionOptions{Passphrase: "passphrase", Profile: "rfc2440"},
+			expectedError: `unsupported profile "rfc2440"`,
+		},
+		{
+			name:          "passphrase in rfc9580 profile with recipient without AEAD support",
+			publicKeys:    []string{publicKeyRSA},
+			options:       EncryptionOptions{Passphrase: "passphrase", Profile: ProfileRFC9580},
+			expectedError: `S2K mode "argon2" requires AEAD encryption, which key 4f54663daabdbaff does not support`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipients, err := GetRecipients(tc.publicKeys)
+			require.NoError(t, err)
+			result, err := EncryptAndEncodeMessageWithOptions(recipients, strings.NewReader("hello world"), tc.options)
+			require.EqualError(t, err, tc.expectedError)
+			assert.Empty(t, result)
+		})
+	}
+}
+
 func TestGetKeyID(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -117,6 +479,8 @@ func TestGetUserEmail(t *testing.T) {
 		{name: "rsa (after expiry-date)", publicKey: publicKeyRSAExpired, time: time.Date(2024, 9, 25, 16, 0, 0, 0, time.UTC), expectedEmail: "foo@coop.no"},
 		{name: "curve (before expiry-date)", publicKey: publicKeyCurveExpired, time: time.Date(2024, 9, 25, 0, 0, 0, 0, time.UTC), expectedEmail: "foo@coop.no"},
 		{name: "curve (after expiry-date)", publicKey: publicKeyCurveExpired, time: time.Date(2024, 9, 25, 16, 0, 0, 0, time.UTC), expectedEmail: "foo@coop.no"},
+		{name: "rsa (ignoring expiration)", publicKey: publicKeyRSAExpired, expectedEmail: "foo@coop.no"},
+		{name: "curve (ignoring expiration)", publicKey: publicKeyCurveExpired, expectedEmail: "foo@coop.no"},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v3/constants"
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/ProtonMail/gopenpgp/v3/profile"
+)
+
+// Supported algorithms for key generation.
+const (
+	// KeyAlgorithmRSA3072 is an RSA key of 3072 bits.
+	KeyAlgorithmRSA3072 = "rsa3072"
+	// KeyAlgorithmRSA4096 is an RSA key of 4096 bits.
+	KeyAlgorithmRSA4096 = "rsa4096"
+	// KeyAlgorithmCurve25519 is an EdDSA signing key with an ECDH encryption subkey over Curve25519.
+	KeyAlgorithmCurve25519 = "curve25519"
+	// KeyAlgorithmEd448 is an Ed448 signing key with an X448 encryption subkey, as defined in RFC 9580.
+	KeyAlgorithmEd448 = "ed448"
+)
+
+// KeyAlgorithms returns all the algorithms supported by GenerateKey.
+func KeyAlgorithms() []string {
+	return []string{KeyAlgorithmRSA3072, KeyAlgorithmRSA4096, KeyAlgorithmCurve25519, KeyAlgorithmEd448}
+}
+
+// UserID is an identity to add to a generated key.
+type UserID struct {
+	Name  string
+	Email string
+}
+
+// KeyOptions configures GenerateKey.
+type KeyOptions struct {
+	// Algorithm is one of the KeyAlgorithm constants.
+	Algorithm string
+	// UserIDs are added to the key. The first one becomes the primary identity.
+	UserIDs []UserID
+	// Lifetime of the key. Zero means the key never expires.
+	Lifetime time.Duration
+	// Passphrase to protect the private key with. Empty means no protection.
+	Passphrase string
+}
+
+// GeneratedKey is a generated key pair.
+type GeneratedKey struct {
+	// PrivateKeyArmored is the private key in Armor-encoding, locked with
+	// the passphrase if one was given.
+	PrivateKeyArmored string
+	// PublicKeyArmored is the public key in Armor-encoding.
+	PublicKeyArmored string
+	// KeyID is the key ID, hex encoded.
+	KeyID string
+	// Fingerprint is the fingerprint of the primary key, hex encoded.
+	Fingerprint string
+}
+
+// GenerateKey generates a new key pair.
+func GenerateKey(options KeyOptions) (*GeneratedKey, error) {
+	if len(options.UserIDs) == 0 {
+		return nil, fmt.Errorf("no user IDs")
+	}
+
+	var keyProfile *profile.Custom
+	var securityLevel int8
+
+	switch options.Algorithm {
+	case KeyAlgorithmRSA3072:
+		keyProfile, securityLevel = profile.RFC4880(), constants.StandardSecurity
+	case KeyAlgorithmRSA4096:
+		keyProfile, securityLevel = profile.RFC4880(), constants.HighSecurity
+	case KeyAlgorithmCurve25519:
+		keyProfile, securityLevel = profile.Default(), constants.StandardSecurity
+	case KeyAlgorithmEd448:
+		keyProfile, securityLevel = profile.RFC9580(), constants.HighSecurity
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", options.Algorithm)
+	}
+
+	lifetimeSeconds := int64(options.Lifetime / time.Second)
+	if lifetimeSeconds < 0 || lifetimeSeconds > math.MaxInt32 {
+		return nil, fmt.Errorf("key lifetime %s is out of range", options.Lifetime)
+	}
+
+	pgp := protonpgp.PGPWithProfile(keyProfile)
+
+	builder := pgp.KeyGeneration().Lifetime(int32(lifetimeSeconds))
+	for _, userID := range options.UserIDs {
+		builder = builder.AddUserId(userID.Name, userID.Email)
+	}
+
+	key, err := builder.New().GenerateKeyWithSecurity(securityLevel)
+	if err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	publicKeyArmored, err := key.GetArmoredPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("encoding public key: %w", err)
+	}
+
+	if options.Passphrase != "" {
+		key, err = pgp.LockKey(key, []byte(options.Passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("locking private key: %w", err)
+		}
+	}
+
+	privateKeyArmored, err := key.Armor()
+	if err != nil {
+		return nil, fmt.Errorf("encoding private key: %w", err)
+	}
+
+	return &GeneratedKey{
+		PrivateKeyArmored: privateKeyArmored,
+		PublicKeyArmored:  publicKeyArmored,
+		KeyID:             key.GetHexKeyID(),
+		Fingerprint:       key.GetFingerprint(),
+	}, nil
+}
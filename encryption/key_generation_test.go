@@ -0,0 +1,89 @@
+package encryption
+
+import (
+	"testing"
+	"time"
+
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKey(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options KeyOptions
+	}{
+		{name: "rsa3072", options: KeyOptions{Algorithm: KeyAlgorithmRSA3072}},
+		{name: "rsa4096", options: KeyOptions{Algorithm: KeyAlgorithmRSA4096}},
+		{name: "curve25519", options: KeyOptions{Algorithm: KeyAlgorithmCurve25519}},
+		{name: "ed448", options: KeyOptions{Algorithm: KeyAlgorithmEd448}},
+		{name: "curve25519 (protected)", options: KeyOptions{Algorithm: KeyAlgorithmCurve25519, Passphrase: "secret"}},
+		{name: "ed448 (protected)", options: KeyOptions{Algorithm: KeyAlgorithmEd448, Passphrase: "secret"}},
+		{name: "curve25519 (expiring)", options: KeyOptions{Algorithm: KeyAlgorithmCurve25519, Lifetime: 24 * time.Hour}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.options.UserIDs = []UserID{{Name: "foo", Email: "foo@coop.no"}, {Email: "bar@coop.no"}}
+			key, err := GenerateKey(tc.options)
+			require.NoError(t, err)
+
+			recipient, err := GetRecipient(key.PublicKeyArmored)
+			require.NoError(t, err)
+			assert.Equal(t, key.KeyID, recipient.GetKeyID())
+			assert.Equal(t, key.Fingerprint, recipient.GetFingerprint())
+			email, ok := recipient.GetUserEmail(time.Now())
+			assert.True(t, ok)
+			assert.Equal(t, "foo@coop.no", email)
+			assert.False(t, recipient.IsExpired(time.Now()))
+			if tc.options.Lifetime != 0 {
+				assert.True(t, recipient.IsExpired(time.Now().Add(tc.options.Lifetime+time.Minute)))
+			}
+
+			signer, err := GetSigner(key.PrivateKeyArmored, tc.options.Passphrase)
+			require.NoError(t, err)
+			assert.Equal(t, key.KeyID, signer.GetKeyID())
+
+			if tc.options.Passphrase != "" {
+				_, err := GetSigner(key.PrivateKeyArmored, "")
+				require.ErrorContains(t, err, "is protected, but no passphrase was given")
+			}
+
+			// Generated key must be usable for both encryption and signing.
+			message := "hello world"
+			encrypted, err := EncryptSignAndEncodeMessage([]*Recipient{recipient}, signer, message)
+			require.NoError(t, err)
+
+			decryptionKey, err := protonpgp.NewPrivateKeyFromArmored(key.PrivateKeyArmored, []byte(tc.options.Passphrase))
+			require.NoError(t, err)
+			decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(decryptionKey).VerificationKey(recipient.protonKey).New()
+			require.NoError(t, err)
+			decrypted, err := decrypter.Decrypt([]byte(encrypted), protonpgp.Armor)
+			require.NoError(t, err)
+			require.NoError(t, decrypted.SignatureError())
+			assert.Equal(t, message, decrypted.String())
+		})
+	}
+}
+
+func TestGenerateKeyInvalidOptions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		options       KeyOptions
+		expectedError string
+	}{
+		{name: "no user IDs", options: KeyOptions{Algorithm: KeyAlgorithmCurve25519}, expectedError: "no user IDs"},
+		{name: "unsupported algorithm", options: KeyOptions{Algorithm: "dsa1024", UserIDs: []UserID{{Email: "foo@coop.no"}}}, expectedError: `unsupported key algorithm "dsa1024"`},
+		{name: "negative lifetime", options: KeyOptions{Algorithm: KeyAlgorithmCurve25519, UserIDs: []UserID{{Email: "foo@coop.no"}}, Lifetime: -time.Hour}, expectedError: "key lifetime -1h0m0s is out of range"},
+		{name: "empty user ID", options: KeyOptions{Algorithm: KeyAlgorithmCurve25519, UserIDs: []UserID{{}}}, expectedError: "generating key"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := GenerateKey(tc.options)
+			require.ErrorContains(t, err, tc.expectedError)
+			assert.Nil(t, key)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package encryption
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	openpgp "github.com/ProtonMail/go-crypto/openpgp/v2"
+)
+
+// KeyInfo describes a public key and its subkeys.
+type KeyInfo struct {
+	KeyID       string
+	Fingerprint string
+	// Algorithm is the public key algorithm of the primary key, like "rsa" or "eddsa".
+	Algorithm string
+	// BitLength is the size of the primary key in bits. For elliptic curve keys,
+	// it is the size of the curve.
+	BitLength    int
+	CreationTime time.Time
+	// ExpirationTime is zero, if the key never expires.
+	ExpirationTime time.Time
+	IsExpired      bool
+	IsRevoked      bool
+	// UserIDs are all identities of the key. The primary identity comes first.
+	UserIDs []UserID
+	Subkeys []SubkeyInfo
+}
+
+// SubkeyInfo describes a subkey and what it can be used for.
+type SubkeyInfo struct {
+	KeyID       string
+	Fingerprint string
+	Algorithm   string
+	BitLength   int
+	// CreationTime is the time the subkey was created.
+	CreationTime time.Time
+	// ExpirationTime is zero, if neither the subkey nor the primary key expires.
+	ExpirationTime  time.Time
+	IsExpired       bool
+	IsRevoked       bool
+	CanSign         bool
+	CanEncrypt      bool
+	CanCertify      bool
+	CanAuthenticate bool
+}
+
+// GetKeyInfo describes the key. Expiration and revocation are evaluated at the
+// given point in time.
+func (r *Recipient) GetKeyInfo(t time.Time) (*KeyInfo, error) {
+	entity := r.protonKey.GetEntity()
+
+	algorithm, bitLength, err := describePublicKey(entity.PrimaryKey)
+	if err != nil {
+		return nil, fmt.Errorf("describing primary key: %w", err)
+	}
+
+	info := &KeyInfo{
+		KeyID:        r.GetKeyID(),
+		Fingerprint:  r.GetFingerprint(),
+		Algorithm:    algorithm,
+		BitLength:    bitLength,
+		CreationTime: entity.PrimaryKey.CreationTime.UTC(),
+		IsExpired:    r.IsExpired(t),
+		IsRevoked:    r.protonKey.IsRevoked(t.UTC().Unix()),
+		UserIDs:      getUserIDs(entity, t),
+	}
+
+	// Zero time ignores expiration, so the self-signature of an expired key is still found.
+	if selfSig, err := entity.PrimarySelfSignature(time.Time{}, nil); err == nil {
+		info.ExpirationTime = expirationTime(entity.PrimaryKey, selfSig)
+	}
+
+	for i := range entity.Subkeys {
+		subkey, err := describeSubkey(&entity.Subkeys[i], t)
+		if err != nil {
+			return nil, fmt.Errorf("describing subkey (idx %d): %w", i, err)
+		}
+
+		// Subkeys can not outlive the primary key.
+		if !info.ExpirationTime.IsZero() && (subkey.ExpirationTime.IsZero() || subkey.ExpirationTime.After(info.ExpirationTime)) {
+			subkey.ExpirationTime = info.ExpirationTime
+		}
+		subkey.IsExpired = subkey.IsExpired || info.IsExpired
+		subkey.IsRevoked = subkey.IsRevoked || info.IsRevoked
+
+		info.Subkeys = append(info.Subkeys, *subkey)
+	}
+
+	return info, nil
+}
+
+func describeSubkey(subkey *openpgp.Subkey, t time.Time) (*SubkeyInfo, error) {
+	algorithm, bitLength, err := describePublicKey(subkey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &SubkeyInfo{
+		KeyID:        fmt.Sprintf("%016x", subkey.PublicKey.KeyId),
+		Fingerprint:  fmt.Sprintf("%x", subkey.PublicKey.Fingerprint),
+		Algorithm:    algorithm,
+		BitLength:    bitLength,
+		CreationTime: subkey.PublicKey.CreationTime.UTC(),
+	}
+
+	// Subkey without a valid binding signature can not be used for anything.
+	bindingSig, bindingErr := subkey.LatestValidBindingSignature(time.Time{}, nil)
+	if bindingErr != nil {
+		info.IsRevoked = subkey.Revoked(nil, t)
+		return info, nil
+	}
+
+	info.ExpirationTime = expirationTime(subkey.PublicKey, bindingSig)
+	info.IsExpired = subkey.Expired(bindingSig, t)
+	info.IsRevoked = subkey.Revoked(bindingSig, t)
+
+	if bindingSig.FlagsValid {
+		info.CanSign = bindingSig.FlagSign
+		info.CanEncrypt = bindingSig.FlagEncryptCommunications || bindingSig.FlagEncryptStorage
+		info.CanCertify = bindingSig.FlagCertify
+		info.CanAuthenticate = bindingSig.FlagAuthenticate
+	}
+
+	return info, nil
+}
+
+// getUserIDs returns all identities of the entity, starting with the primary
+// one, followed by the rest sorted by their full user ID.
+func getUserIDs(entity *openpgp.Entity, t time.Time) []UserID {
+	var primaryName string
+	if _, primary := entity.PrimaryIdentity(t, nil); primary != nil {
+		primaryName = primary.Name
+	}
+
+	names := make([]string, 0, len(entity.Identities))
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == primaryName) != (names[j] == primaryName) {
+			return names[i] == primaryName
+		}
+		return names[i] < names[j]
+	})
+
+	userIDs := make([]UserID, 0, len(names))
+	for _, name := range names {
+		identity := entity.Identities[name]
+		if identity.UserId == nil {
+			continue
+		}
+		userIDs = append(userIDs, UserID{Name: identity.UserId.Name, Email: identity.UserId.Email})
+	}
+
+	return userIDs
+}
+
+func expirationTime(key *packet.PublicKey, selfSig *packet.Signature) time.Time {
+	if selfSig.KeyLifetimeSecs == nil || *selfSig.KeyLifetimeSecs == 0 {
+		return time.Time{}
+	}
+	return key.CreationTime.Add(time.Duration(*selfSig.KeyLifetimeSecs) * time.Second).UTC()
+}
+
+var publicKeyAlgorithms = map[packet.PublicKeyAlgorithm]string{
+	packet.PubKeyAlgoRSA:            "rsa",
+	packet.PubKeyAlgoRSAEncryptOnly: "rsa",
+	packet.PubKeyAlgoRSASignOnly:    "rsa",
+	packet.PubKeyAlgoElGamal:        "elgamal",
+	packet.PubKeyAlgoDSA:            "dsa",
+	packet.PubKeyAlgoECDH:           "ecdh",
+	packet.PubKeyAlgoECDSA:          "ecdsa",
+	packet.PubKeyAlgoEdDSA:          "eddsa",
+	packet.PubKeyAlgoX25519:         "x25519",
+	packet.PubKeyAlgoX448:           "x448",
+	packet.PubKeyAlgoEd25519:        "ed25519",
+	packet.PubKeyAlgoEd448:          "ed448",
+}
+
+var curveBitLengths = map[packet.Curve]int{
+	packet.Curve25519:         255,
+	packet.Curve448:           448,
+	packet.CurveNistP256:      256,
+	packet.CurveNistP384:      384,
+	packet.CurveNistP521:      521,
+	packet.CurveSecP256k1:     256,
+	packet.CurveBrainpoolP256: 256,
+	packet.CurveBrainpoolP384: 384,
+	packet.CurveBrainpoolP512: 512,
+}
+
+func describePublicKey(key *packet.PublicKey) (string, int, error) {
+	algorithm, ok := publicKeyAlgorithms[key.PubKeyAlgo]
+	if !ok {
+		return "", 0, fmt.Errorf("unsupported public key algorithm %d", key.PubKeyAlgo)
+	}
+
+	// Encoded elliptic curve points are longer than the curve, so report the
+	// size of the curve, like GnuPG does.
+	if curve, err := key.Curve(); err == nil {
+		if bitLength, ok := curveBitLengths[curve]; ok {
+			return algorithm, bitLength, nil
+		}
+	}
+
+	bitLength, err := key.BitLength()
+	if err != nil {
+		return "", 0, fmt.Errorf("getting bit length: %w", err)
+	}
+
+	return algorithm, int(bitLength), nil
+}
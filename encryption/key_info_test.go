@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetKeyInfo(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name      string
+		publicKey string
+		expected  KeyInfo
+	}{
+		{
+			name:      "rsa",
+			publicKey: publicKeyRSA,
+			expected: KeyInfo{
+				KeyID:        "4f54663daabdbaff",
+				Fingerprint:  "40b59cc2ed3da2213fd0aa5c4f54663daabdbaff",
+				Algorithm:    "rsa",
+				BitLength:    4096,
+				CreationTime: time.Date(2024, 9, 17, 14, 10, 3, 0, time.UTC),
+				UserIDs:      []UserID{{Name: "foo", Email: "bar@foo.com"}},
+				Subkeys: []SubkeyInfo{{
+					KeyID:        "be063ec5c1e161a7",
+					Fingerprint:  "37b262e0bab1419b1eab470fbe063ec5c1e161a7",
+					Algorithm:    "rsa",
+					BitLength:    4096,
+					CreationTime: time.Date(2024, 9, 17, 14, 10, 3, 0, time.UTC),
+					CanEncrypt:   true,
+				}},
+			},
+		},
+		{
+			name:      "curve",
+			publicKey: publicKeyCurve,
+			expected: KeyInfo{
+				KeyID:        "27076d92c444bc87",
+				Fingerprint:  "f7a25236fede875f6308be6627076d92c444bc87",
+				Algorithm:    "eddsa",
+				BitLength:    255,
+				CreationTime: time.Date(2024, 9, 17, 14, 18, 19, 0, time.UTC),
+				UserIDs:      []UserID{{Name: "foobar-ecc25519", Email: "foo@bar-curve.com"}},
+				Subkeys: []SubkeyInfo{{
+					KeyID:        "94810cd7e7be635c",
+					Fingerprint:  "350df427366e5b59da52bd6c94810cd7e7be635c",
+					Algorithm:    "ecdh",
+					BitLength:    255,
+					CreationTime: time.Date(2024, 9, 17, 14, 18, 19, 0, time.UTC),
+					CanEncrypt:   true,
+				}},
+			},
+		},
+		{
+			name:      "expired curve",
+			publicKey: publicKeyCurveExpired,
+			expected: KeyInfo{
+				KeyID:          "9edb3fd181a2ee9f",
+				Fingerprint:    "d8a9fe58bea659391452ce3d9edb3fd181a2ee9f",
+				Algorithm:      "eddsa",
+				BitLength:      255,
+				CreationTime:   time.Date(2024, 9, 24, 9, 23, 42, 0, time.UTC),
+				ExpirationTime: time.Date(2024, 9, 25, 9, 23, 42, 0, time.UTC),
+				IsExpired:      true,
+				UserIDs:        []UserID{{Name: "expired curve key", Email: "foo@coop.no"}},
+				Subkeys: []SubkeyInfo{{
+					KeyID:          "9d71fbfd2ea7c711",
+					Fingerprint:    "489a2813bb9a91ef4d8e4a119d71fbfd2ea7c711",
+					Algorithm:      "ecdh",
+					BitLength:      255,
+					CreationTime:   time.Date(2024, 9, 24, 9, 23, 42, 0, time.UTC),
+					ExpirationTime: time.Date(2024, 9, 25, 9, 23, 42, 0, time.UTC),
+					IsExpired:      true,
+					CanEncrypt:     true,
+				}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipient, err := GetRecipient(tc.publicKey)
+			require.NoError(t, err)
+			info, err := recipient.GetKeyInfo(now)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, *info)
+		})
+	}
+}
+
+func TestGetKeyInfoGeneratedKey(t *testing.T) {
+	key, err := GenerateKey(KeyOptions{
+		Algorithm: KeyAlgorithmEd448,
+		UserIDs: []UserID{
+			{Name: "Zulu", Email: "zulu@coop.no"},
+			{Name: "Alpha", Email: "alpha@coop.no"},
+			{Email: "bravo@coop.no"},
+		},
+		Lifetime: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	recipient, err := GetRecipient(key.PublicKeyArmored)
+	require.NoError(t, err)
+
+	now := time.Now()
+	info, err := recipient.GetKeyInfo(now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ed448", info.Algorithm)
+	assert.Equal(t, 448, info.BitLength)
+	assert.False(t, info.IsExpired)
+	assert.WithinDuration(t, info.CreationTime.Add(24*time.Hour), info.ExpirationTime, time.Second)
+	assert.Equal(t, []UserID{
+		{Name: "Zulu", Email: "zulu@coop.no"},
+		{Email: "bravo@coop.no"},
+		{Name: "Alpha", Email: "alpha@coop.no"},
+	}, info.UserIDs)
+	require.Len(t, info.Subkeys, 1)
+	assert.Equal(t, "x448", info.Subkeys[0].Algorithm)
+	assert.True(t, info.Subkeys[0].CanEncrypt)
+	assert.False(t, info.Subkeys[0].CanSign)
+	assert.Equal(t, info.ExpirationTime, info.Subkeys[0].ExpirationTime)
+
+	info, err = recipient.GetKeyInfo(now.Add(48 * time.Hour))
+	require.NoError(t, err)
+	assert.True(t, info.IsExpired)
+	assert.True(t, info.Subkeys[0].IsExpired)
+}
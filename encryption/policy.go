@@ -0,0 +1,113 @@
+package encryption
+
+import (
+	"crypto"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// Algorithms, which are considered weak, as reported by KeyInfo.
+const (
+	// AlgorithmDSA is the DSA signature algorithm.
+	AlgorithmDSA = "dsa"
+	// AlgorithmElGamal is the ElGamal encryption algorithm.
+	AlgorithmElGamal = "elgamal"
+)
+
+// Algorithms returns names of all public key algorithms, as reported by KeyInfo.
+func Algorithms() []string {
+	algorithms := []string{}
+	for _, algorithm := range publicKeyAlgorithms {
+		if !slices.Contains(algorithms, algorithm) {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+
+	slices.Sort(algorithms)
+
+	return algorithms
+}
+
+// AlgorithmPolicy restricts parameters of the public keys, which messages are
+// encrypted with. Zero value allows all keys.
+type AlgorithmPolicy struct {
+	// MinRSABits is the minimum size of RSA keys. Zero allows RSA keys of any size.
+	MinRSABits int
+	// ForbiddenAlgorithms are public key algorithms, like AlgorithmDSA, which keys must not use.
+	ForbiddenAlgorithms []string
+	// ForbidSHA1 rejects keys, of which self-signatures are made with SHA-1.
+	ForbidSHA1 bool
+}
+
+// Check returns violations of the policy by the primary key of the recipient,
+// and by its subkeys, which are valid for encryption at the given point in
+// time.
+func (p AlgorithmPolicy) Check(r *Recipient, t time.Time) ([]string, error) {
+	entity := r.protonKey.GetEntity()
+	violations := []string{}
+
+	primaryViolations, err := p.checkPublicKey("primary key", entity.PrimaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	violations = append(violations, primaryViolations...)
+
+	// Zero time ignores expiration, as validity of keys is checked separately.
+	if selfSig, err := entity.PrimarySelfSignature(time.Time{}, nil); err == nil {
+		violations = append(violations, p.checkSignature("self-signature of primary key", selfSig)...)
+	}
+
+	// Subkeys with unsupported algorithms are not selected for encryption by
+	// the library, so all subkeys valid for encryption are checked.
+	for i := range entity.Subkeys {
+		subkey := &entity.Subkeys[i]
+
+		bindingSig, err := subkey.LatestValidBindingSignature(t, nil)
+		if err != nil || !bindingSig.FlagsValid || !(bindingSig.FlagEncryptCommunications || bindingSig.FlagEncryptStorage) {
+			continue
+		}
+
+		name := fmt.Sprintf("encryption subkey %016x", subkey.PublicKey.KeyId)
+
+		subkeyViolations, err := p.checkPublicKey(name, subkey.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		violations = append(violations, subkeyViolations...)
+		violations = append(violations, p.checkSignature("binding signature of "+name, bindingSig)...)
+	}
+
+	return violations, nil
+}
+
+func (p AlgorithmPolicy) checkPublicKey(name string, key *packet.PublicKey) ([]string, error) {
+	algorithm, bitLength, err := describePublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("describing %s: %w", name, err)
+	}
+
+	violations := []string{}
+
+	if slices.Contains(p.ForbiddenAlgorithms, algorithm) {
+		violations = append(violations, fmt.Sprintf("%s uses forbidden algorithm %s", name, algorithm))
+	}
+
+	if algorithm == "rsa" && bitLength < p.MinRSABits {
+		violations = append(violations, fmt.Sprintf("%s is RSA of %d bits, which is shorter than %d bits", name, bitLength, p.MinRSABits))
+	}
+
+	return violations, nil
+}
+
+func (p AlgorithmPolicy) checkSignature(name string, signature *packet.Signature) []string {
+	if p.ForbidSHA1 && signature.Hash == crypto.SHA1 {
+		return []string{fmt.Sprintf("%s uses SHA-1", name)}
+	}
+
+	return nil
+}
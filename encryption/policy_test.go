@@ -0,0 +1,162 @@
+package encryption
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// strictAlgorithmPolicy allows no RSA keys shorter than 3072 bits, no DSA or
+// ElGamal keys, and no SHA-1 self-signatures.
+var strictAlgorithmPolicy = AlgorithmPolicy{
+	MinRSABits:          3072,
+	ForbiddenAlgorithms: []string{AlgorithmDSA, AlgorithmElGamal},
+	ForbidSHA1:          true,
+}
+
+func TestAlgorithmPolicyCheck(t *testing.T) {
+	testCases := []struct {
+		name               string
+		publicKey          string
+		policy             AlgorithmPolicy
+		expectedViolations []string
+	}{
+		{name: "rsa", publicKey: publicKeyRSA, policy: strictAlgorithmPolicy, expectedViolations: []string{}},
+		{name: "curve", publicKey: publicKeyCurve, policy: strictAlgorithmPolicy, expectedViolations: []string{}},
+		{
+			name:      "rsa 2048",
+			publicKey: publicKeyRSAWeak,
+			policy:    strictAlgorithmPolicy,
+			expectedViolations: []string{
+				"primary key is RSA of 2048 bits, which is shorter than 3072 bits",
+				"encryption subkey c3a5555566be1618 is RSA of 2048 bits, which is shorter than 3072 bits",
+			},
+		},
+		{name: "rsa 2048 (zero policy)", publicKey: publicKeyRSAWeak, expectedViolations: []string{}},
+		{
+			name:      "dsa and elgamal",
+			publicKey: publicKeyDSA,
+			policy:    strictAlgorithmPolicy,
+			expectedViolations: []string{
+				"primary key uses forbidden algorithm dsa",
+				"encryption subkey bd7d6b21f849992b uses forbidden algorithm elgamal",
+			},
+		},
+		{
+			name:               "elgamal only",
+			publicKey:          publicKeyDSA,
+			policy:             AlgorithmPolicy{ForbiddenAlgorithms: []string{AlgorithmElGamal}},
+			expectedViolations: []string{"encryption subkey bd7d6b21f849992b uses forbidden algorithm elgamal"},
+		},
+		{
+			name:      "sha-1 self-signatures",
+			publicKey: publicKeySHA1,
+			policy:    strictAlgorithmPolicy,
+			expectedViolations: []string{
+				"self-signature of primary key uses SHA-1",
+				"binding signature of encryption subkey a865c0a966d30d79 uses SHA-1",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipient, err := GetRecipient(tc.publicKey)
+			require.NoError(t, err)
+
+			violations, err := tc.policy.Check(recipient, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedViolations, violations)
+		})
+	}
+}
+
+// publicKeyRSAWeak is RSA 2048 key with RSA 2048 encryption subkey.
+var publicKeyRSAWeak = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mQENBGrTj7UBCADORmiMcaemgL2uXb57gHLOMWA2gmp6yz+zU82PG6VuLwVPSPiy
+8+bWdGUSKs1bRYImzuOD7lHQC9BWG1IeUfkgzOR7FZFPltAgvaXTdBCT0q91fS4G
+3AvP32x/M/ccExJj5ZzRwfXa0sdgVwZbm5DuTASrGj9Lwo/n0ipKaRNRHb8ZfzN+
+1kyyPcFtdn5qQfDdxsVScG2S+yRl+UTByJCIubRgz/e5ZLBVtz4snu4Vdaiz4J6e
+FkyGi5Tt5YVliu4m5HrWAeGQz90KcbkUFd+ZdP5E/FRy+w6Z7vPDKgZtIvChy/hT
+9sooxuhqkbGkA7ZLf5FSsWGgwUmtQwhh2c9DABEBAAG0G1dlYWsgUlNBIDx3ZWFr
+LXJzYUBjb29wLm5vPokBTgQTAQoAOBYhBBYd0hCghJWTBDQSnvsj6iNLhMUBBQJq
+04+1AhsDBQsJCAcCBhUKCQgLAgQWAgMBAh4BAheAAAoJEPsj6iNLhMUBKh4IAL2F
+YpJ09JO2tEVdZ0cENhhIB+6A3NiE4ic2mpUOq2cRWS6eJ4YzIOkt1+Cdn8vcQCOK
+9iMEZmhY1wINn6fKslU76csGXoXRpxhHPHcc+7ecwqv83FpKlxmVSaWlA+EkpJuS
+LpvyOxGcFAWmYz/YP5e8lvjby5MIIVoYZohemf1rIoJQ0WUvZ3kQhjyrAH0Gwi1F
+9kSwgsIVk/YZv9yWTR3xuxfNdNVAVzhBXJk5GKQ8f1LbrAjTzmkXR1G6k2S+osyD
+x/+jFa0shwZZbHTnRzu6PwtblTR+7zFTFYREYvODYz4MkyBBLbrz+EMSTj8z16At
+KCi8xn+1FyApvcY9j3K5AQ0EatOPugEIAK8k4V/4+Gsl3MBWWIMP6/gFOATgIiVs
+o48TATOU0ujVBf8htfu2OgiibZz+AwC71vCOe456SeuBXUE6q3DyjeAbKwzFnI6H
+dpBq1Xe9RZ82IAMO6d6F9YwzgX6jFiHiRiReqNFILQpecGfT6sncVKHoMHDbCrPV
+laxsiZMf4c/KIaOwOBoZsD0IPWTMXwJwPNA+LNTbKbgOTAuP1oEhFScWlmGjxbbd
+C6ByiyfKuFNcx/FbCNEnWvlclnOTPJVEIABMg9c8bOWMF1/CeyEw8G9zupWvAl7P
+J8ax0UhQgoHJllqUxptdoJzNfA4ETMFMu2VhH3UQ6HfUO2c+J0g9fPsAEQEAAYkB
+NgQYAQoAIBYhBBYd0hCghJWTBDQSnvsj6iNLhMUBBQJq04+6AhsMAAoJEPsj6iNL
+hMUB02YIAL4rWpPk+aj55ICDI7GXM013OepVFRgSeD75KUMoF+4bSYMrTv15nd6X
+OKnDOEJdsJBoTQKx6oPZc953s80Woqr+WoCIIrk0lHZZPa8iz10mFrYuJfHNWIJe
+O55vTIyrb4qTi0trzM4REgTGA15gbwlSkKhSqdw+Ynk8CPTAULrEStDbeL1zDkAl
+7iJAvkA44p2OWpUKOQKeKfdDiegVAW4c0vRPnVkCq9nQgfQLsqA9fgGj13K8oSkO
+fuUBrk3S149GxKu2iZpIBJsQNrefJQFvzt37sO6cSYWx1lQBlbJ5ls879dReMSZ3
+EUqhbNeAWPEg4QqoW3HtK1M6UEZs2xo=
+=/D6c
+-----END PGP PUBLIC KEY BLOCK-----`
+
+// publicKeyDSA is DSA 2048 key with ElGamal 2048 encryption subkey.
+var publicKeyDSA = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mQMuBGrTj7URCADo5DWs+II4I6wKUVqX9OfIE0ipoqthcXzXPd1KEMjGxW0xzdOG
+WL6DSqj+ERuQKiBUIxASV7KdNJnRYU2RZox9wBlNuIpGt10Q9+1kK9sSq4Jw/gz5
+kRuIPV9pm6hCHwSaed7MTFjP4lvWQ7kZEa52Rx+QTtAU2a2y8IFPKgbX6eh6l6P1
+oNj7UDLhea0qWKKaPEXZNd9yiAesj23jZO81UFjiPEodRX5apVqLtQSJbpQpR4j3
+CiR/g+Cx3GhD2KeVJZzO/qLf/HtVjYpIGdMROohBxVsh6V8IgggkkgEFX5hYQwdo
+nbA4PbxNnrA0czzAkgtmub1QIEWLpGNM6bXPAQCR+sO8K7yOcytbH+J2+pymjRz7
+KV1JU0cjMn5rFu7xOwgA1RIKpcOroAVSlvWsuQumm4XlzvMLrRpdPxaU5/FC2M5M
+vALK9uIH4Jo7OeXfy9cVIWcsEZp2Zg+wPl3hOd08sWVXXn+rgQxoxNRD1RUIfPt+
+11+9aJVb+tTuZU9doRn6xGsnehdRfveS9wq1a9qufh2ij5HzKjJTXH2FlVTtF8S/
+3k5cLH++/kUT9jbf7MMClpTFlyWex0IeqLk2VyqsngL183zEdn3nAJ6lkv9q2o+V
+XfsfZHGh2QXyAmWpIInEzWV9gj24vDIbZrZPEvAoa8D9inlQGVhf/2N2g0lAp8H5
+Wd3TTw4ZJH9yZfxKVGa1W8mMNsowou9d0za6JuK7ugf5Ade0HJUOTZb93kgjt6hJ
+TFDh0Dv/CaQ6su1YYGzqZT9IYYNgUI/dOhXiceWmlPj9xOsxXUYhYlFFHr4qX70c
+ABh6vlOG6AXnvpFkBwrh4x/s0Y8Y3m26eQaQ+doPeA57RWxueJV2K4c/PHbMlUgb
+7O1r0tULrDwrEPX3li/d3oHdIlmLRZPOWhninuoNAHIHP+yH8ZlVh9h//fzFq9Yh
+ijo1jniiO+TrzMbt+BH5XjmOULreRi7OgMpviOfeCj7Bin16LJ9ef7Arwxg7+1GX
+8me6Dfu5j2N13IOZ0wqyfBvPjk/piVsoKormtZJ7g14VEeEdAjiIrKfhK+3PC1Xx
+2rQRRFNBIDxkc2FAY29vcC5ubz6IkAQTEQgAOBYhBNM4D3HdwPZzfine0RUdyB7k
+17AVBQJq04+1AhsDBQsJCAcCBhUKCQgLAgQWAgMBAh4BAheAAAoJEBUdyB7k17AV
+hjIA/iJq3SeyIgGgUmAG/Sy+TKvMJEfACZrudj+45w4dF2QLAP48Ix4NowUe7Jw0
+MlXnIeoqLsoO/hnEx2k3GFlF4ITjpbkCDQRq04+2EAgA6wZ/SiHiLxmpJDHxuSd0
+AyiTfsqVHYVf2AY63EnXIsplgPAndf/O1ZyodYBCQ4rt/GDbnW0PMyvCiFnn1KQs
++klRotBEcVQJPQXSYePIB7ARyJNWxlitUTzZZYHqPjey6shVdm0RnxM7LUpodk0H
+gjnZ74JQoJzd1qtqG8Q5J/2DfQko0QVDLltDwqGuE4qjpOCifOgXrvmHX0Ya9oKT
+cXa9y+KvTwNS3U7qMh2YJq9geIRSb7iDBBbEQi08uPnLLDhNq+RsH2mYj8cJoLA5
+icmK3efHpN5jInbQA9iNMZwiEvvdCBMv2FxZavE6CCH+pvl+90Eo3GOwZT4U4jEy
+hwADBQf+MezfznBy9L3Z25nVm0cpSktxGqjxmqMiU2Eu/gtdb5dxHXBDSGxn889x
+YJn5qoWjngJhxJTwTjLH8QhmRPZoCimKkGz+4yCsI6kdRbe1oK5Kf4wwXUA+YX7V
+GAwa1CnT8mx60CMuFfgRxzZx65keRGTsag+2/DqX6pw+whdrTP8psVx2XEwSYWIc
+NN5+UbNxs4964W6p5z2u2dXTHMAOfqTM1ZQ5jtUfWa/iGnR8oEz6ol++atnLPKVm
+Atg+siUizFAJBpXc9Xj8ENIXOK1+zaTUDdO4ShqJcQ6CLBPycwLs2RPKzqO4CTu0
+FWqZ5gurGIkNExxpboFU/s/p54Exyoh4BBgRCAAgFiEE0zgPcd3A9nN+Kd7RFR3I
+HuTXsBUFAmrTj7YCGwwACgkQFR3IHuTXsBWFugD/XamWwtNLJSAcMBU6Ydk38G2Y
+rrriXnIBoGo7RhF6e+EA/RHIQR/35fb7WskruZR33QGR7ogyaMWoqZxgu5VAEgYj
+=FXzm
+-----END PGP PUBLIC KEY BLOCK-----`
+
+// publicKeySHA1 is Ed25519 key with Curve25519 encryption subkey, of which
+// self-signatures are made with SHA-1.
+var publicKeySHA1 = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatOPtxYJKwYBBAHaRw8BAQdANUsMGCM6fXkK/1QWn5epUx8y+phBSTOSZ9/x
+f1Oe7Lm0E1NIQTEgPHNoYTFAY29vcC5ubz6IkAQTFgIAOBYhBOPUFF2bOJMMxPNO
+yz9lwb6701VMBQJq04+3AhsDBQsJCAcCBhUKCQgLAgQWAgMBAh4BAheAAAoJED9l
+wb6701VMxzoBAJL4+RRgMtHu+Xb37L1DHNjYPKBja9lsPaCPJ/frTl6NAQDYLvUu
+eteKO5173xyI40HVUnNxFwwsjqQtkjWUttGwAbg4BGrTj7cSCisGAQQBl1UBBQEB
+B0CinOu7E3+Yt1jhztfr41lYM+gycQgVy91V8yMXoswecwMBCAeIeAQYFgIAIBYh
+BOPUFF2bOJMMxPNOyz9lwb6701VMBQJq04+3AhsMAAoJED9lwb6701VMsTUBAKjC
+51N//TXEF6UWu4FP7QVdMSQsI37dbcbVljEYB+ZYAQDEgnjcYm4lIBmdsCU87+Yv
+CYeCNeVdkADdP5UDtgIJAg==
+=1WK3
+-----END PGP PUBLIC KEY BLOCK-----`
@@ -0,0 +1,135 @@
+package encryption
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+// Signer is our own representation of a private key used for signing.
+// Like Recipient, it does not export any underlying crypto-library's type.
+type Signer struct {
+	protonKey *protonpgp.Key
+}
+
+// GetKeyID returns the key ID of the signing key, hex encoded as a string.
+func (s *Signer) GetKeyID() string {
+	return s.protonKey.GetHexKeyID()
+}
+
+// GetSigner decodes and parses an armor-encoded private key, and unlocks it
+// with the passphrase if the key is protected. The passphrase is ignored for
+// keys which are not protected.
+func GetSigner(privateKey string, passphrase string) (*Signer, error) {
+	key, err := getPrivateKey(privateKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{protonKey: key}, nil
+}
+
+func getPrivateKey(privateKey string, passphrase string) (*protonpgp.Key, error) {
+	key, err := protonpgp.NewKeyFromArmored(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding private key: %w", err)
+	}
+
+	if !key.IsPrivate() {
+		return nil, fmt.Errorf("key %s is not a private key", key.GetHexKeyID())
+	}
+
+	isLocked, err := key.IsLocked()
+	if err != nil {
+		return nil, fmt.Errorf("checking if private key is locked: %w", err)
+	}
+
+	if isLocked {
+		if passphrase == "" {
+			return nil, fmt.Errorf("private key %s is protected, but no passphrase was given", key.GetHexKeyID())
+		}
+
+		key, err = key.Unlock([]byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("unlocking private key: %w", err)
+		}
+	}
+
+	return key, nil
+}
+
+// SignAndEncodeMessage signs the message with the key of the signer.
+// The message and the signature are encoded together in the Armor-encoding.
+func SignAndEncodeMessage(signer *Signer, message string) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("no signer")
+	}
+
+	handle, err := protonpgp.PGP().Sign().SigningKey(signer.protonKey).New()
+	if err != nil {
+		return "", fmt.Errorf("creating signer: %w", err)
+	}
+
+	signedMessage, err := handle.Sign([]byte(message), protonpgp.Armor)
+	if err != nil {
+		return "", fmt.Errorf("signing message: %w", err)
+	}
+
+	return string(signedMessage), nil
+}
+
+// SignCleartext signs the message with the key of the signer, and produces a
+// cleartext signed message, i.e. a message starting with
+// "-----BEGIN PGP SIGNED MESSAGE-----", where the content is readable.
+// Lines of the message starting with a dash are dash-escaped, and trailing
+// whitespace is removed, as described in RFC 9580 section 7.
+// The message must be valid UTF-8.
+func SignCleartext(signer *Signer, message string) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("no signer")
+	}
+
+	handle, err := protonpgp.PGP().Sign().SigningKey(signer.protonKey).New()
+	if err != nil {
+		return "", fmt.Errorf("creating signer: %w", err)
+	}
+
+	signedMessage, err := handle.SignCleartext([]byte(message))
+	if err != nil {
+		return "", fmt.Errorf("signing message: %w", err)
+	}
+
+	return string(signedMessage), nil
+}
+
+// SignDetachedAndEncode creates a detached signature of the message with the
+// key of the signer. The message is streamed, so it can be of any size.
+// The signature is encoded in the Armor-encoding.
+func SignDetachedAndEncode(signer *Signer, message io.Reader) (string, error) {
+	if signer == nil {
+		return "", fmt.Errorf("no signer")
+	}
+
+	handle, err := protonpgp.PGP().Sign().SigningKey(signer.protonKey).Detached().New()
+	if err != nil {
+		return "", fmt.Errorf("creating signer: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	wcSign, err := handle.SigningWriter(buf, protonpgp.Armor)
+	if err != nil {
+		return "", fmt.Errorf("signing message: %w", err)
+	}
+
+	if _, err := io.Copy(wcSign, message); err != nil {
+		return "", fmt.Errorf("writing content to signer: %w", err)
+	}
+
+	if err := wcSign.Close(); err != nil {
+		return "", fmt.Errorf("closing signature: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,122 @@
+package encryption
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+const cleartextHeader = "-----BEGIN PGP SIGNED MESSAGE-----"
+
+// Verification is the result of verifying a signature against a list of trusted keys.
+type Verification struct {
+	// Signer is the trusted key which made the signature, if it was found.
+	Signer *Recipient
+	// SignatureTime is the creation time of the signature, if it was found.
+	SignatureTime time.Time
+	// Content is the signed content of inline and cleartext signed messages.
+	Content []byte
+	// Err describes why the signature is not valid. It is nil for valid signatures.
+	Err error
+}
+
+// IsValid returns whether the signature is valid and made by one of the trusted keys.
+func (v *Verification) IsValid() bool {
+	return v.Err == nil
+}
+
+// VerifyDetached verifies the armor-encoded detached signature of the content,
+// using the trusted keys. The content is streamed, so it can be of any size.
+// An error is only returned if the verification could not be performed, an
+// invalid signature is reported in the returned Verification.
+func VerifyDetached(trusted []*Recipient, content io.Reader, signature string) (*Verification, error) {
+	verifier, err := newVerifier(trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := verifier.VerifyingReader(content, strings.NewReader(signature), protonpgp.Armor)
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+
+	result, err := reader.DiscardAllAndVerifySignature()
+	if err != nil {
+		return nil, fmt.Errorf("verifying signature: %w", err)
+	}
+
+	return newVerification(trusted, result, nil), nil
+}
+
+// VerifyInline verifies the armor-encoded inline signed message, or cleartext
+// signed message, using the trusted keys.
+// An error is only returned if the verification could not be performed, an
+// invalid signature is reported in the returned Verification.
+func VerifyInline(trusted []*Recipient, message string) (*Verification, error) {
+	verifier, err := newVerifier(trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(message), cleartextHeader) {
+		result, err := verifier.VerifyCleartext([]byte(message))
+		if err != nil {
+			return nil, fmt.Errorf("verifying cleartext signed message: %w", err)
+		}
+
+		return newVerification(trusted, &result.VerifyResult, result.Cleartext()), nil
+	}
+
+	result, err := verifier.VerifyInline([]byte(message), protonpgp.Armor)
+	if err != nil {
+		return nil, fmt.Errorf("verifying signed message: %w", err)
+	}
+
+	return newVerification(trusted, &result.VerifyResult, result.Bytes()), nil
+}
+
+func newVerifier(trusted []*Recipient) (protonpgp.PGPVerify, error) {
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("no trusted keys")
+	}
+
+	keyring := &protonpgp.KeyRing{}
+	for i, v := range trusted {
+		if err := keyring.AddKey(v.protonKey); err != nil {
+			return nil, fmt.Errorf("adding key to keyring (index %d): %w", i, err)
+		}
+	}
+
+	verifier, err := protonpgp.PGP().Verify().VerificationKeys(keyring).New()
+	if err != nil {
+		return nil, fmt.Errorf("creating verifier: %w", err)
+	}
+
+	return verifier, nil
+}
+
+func newVerification(trusted []*Recipient, result *protonpgp.VerifyResult, content []byte) *Verification {
+	verification := &Verification{
+		Content: content,
+		Err:     result.SignatureError(),
+	}
+
+	if creationTime := result.SignatureCreationTime(); creationTime != 0 {
+		verification.SignatureTime = time.Unix(creationTime, 0).UTC()
+	}
+
+	if signedBy := result.SignedByKey(); signedBy != nil {
+		for _, recipient := range trusted {
+			if bytes.Equal(recipient.protonKey.GetFingerprintBytes(), signedBy.GetFingerprintBytes()) {
+				verification.Signer = recipient
+				break
+			}
+		}
+	}
+
+	return verification
+}
@@ -0,0 +1,107 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDetached(t *testing.T) {
+	curveSigner, err := GetSigner(privateKeyCurve, "")
+	require.NoError(t, err)
+	content := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  empty.txt\n"
+	signature, err := SignDetachedAndEncode(curveSigner, strings.NewReader(content))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		trustedKeys   []string
+		content       string
+		expectedValid bool
+		expectedKeyID string
+	}{
+		{name: "valid", trustedKeys: []string{publicKeyCurveSigner}, content: content, expectedValid: true, expectedKeyID: "75fac23672df26de"},
+		{name: "valid (multiple trusted keys)", trustedKeys: []string{publicKeyRSA, publicKeyCurveSigner}, content: content, expectedValid: true, expectedKeyID: "75fac23672df26de"},
+		{name: "tampered content", trustedKeys: []string{publicKeyCurveSigner}, content: "tampered", expectedValid: false, expectedKeyID: "75fac23672df26de"},
+		{name: "untrusted signer", trustedKeys: []string{publicKeyRSASigner}, content: content, expectedValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trusted, err := GetRecipients(tc.trustedKeys)
+			require.NoError(t, err)
+
+			verification, err := VerifyDetached(trusted, strings.NewReader(tc.content), signature)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValid, verification.IsValid())
+			if tc.expectedKeyID == "" {
+				assert.Nil(t, verification.Signer)
+				return
+			}
+			require.NotNil(t, verification.Signer)
+			assert.Equal(t, tc.expectedKeyID, verification.Signer.GetKeyID())
+			assert.WithinDuration(t, time.Now(), verification.SignatureTime, time.Minute)
+		})
+	}
+}
+
+func TestVerifyDetachedMalformedSignature(t *testing.T) {
+	trusted, err := GetRecipients([]string{publicKeyCurveSigner})
+	require.NoError(t, err)
+
+	verification, err := VerifyDetached(trusted, strings.NewReader("content"), "not a signature")
+	require.ErrorContains(t, err, "reading signature")
+	assert.Nil(t, verification)
+}
+
+func TestVerifyInline(t *testing.T) {
+	rsaSigner, err := GetSigner(privateKeyRSA, privateKeyRSAPassphrase)
+	require.NoError(t, err)
+	message := "hello world"
+	signedMessage, err := SignAndEncodeMessage(rsaSigner, message)
+	require.NoError(t, err)
+	cleartextMessage, err := SignCleartext(rsaSigner, message)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		trustedKeys   []string
+		message       string
+		expectedValid bool
+	}{
+		{name: "signed message", trustedKeys: []string{publicKeyRSASigner}, message: signedMessage, expectedValid: true},
+		{name: "cleartext signed message", trustedKeys: []string{publicKeyRSASigner}, message: cleartextMessage, expectedValid: true},
+		{name: "signed message (untrusted signer)", trustedKeys: []string{publicKeyCurveSigner}, message: signedMessage, expectedValid: false},
+		{name: "cleartext signed message (untrusted signer)", trustedKeys: []string{publicKeyCurveSigner}, message: cleartextMessage, expectedValid: false},
+		{name: "cleartext signed message (tampered)", trustedKeys: []string{publicKeyRSASigner}, message: strings.Replace(cleartextMessage, "hello world", "hello there", 1), expectedValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trusted, err := GetRecipients(tc.trustedKeys)
+			require.NoError(t, err)
+
+			verification, err := VerifyInline(trusted, tc.message)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValid, verification.IsValid())
+			if tc.expectedValid {
+				assert.Equal(t, message, string(verification.Content))
+				require.NotNil(t, verification.Signer)
+				assert.Equal(t, "d8a1a867bacce331", verification.Signer.GetKeyID())
+				assert.Equal(t, "7b4273093fb8adb18ec2190bd8a1a867bacce331", verification.Signer.GetFingerprint())
+				email, ok := verification.Signer.GetUserEmail(verification.SignatureTime)
+				assert.True(t, ok)
+				assert.Equal(t, "signer-rsa@coop.no", email)
+			}
+		})
+	}
+}
+
+func TestVerifyNoTrustedKeys(t *testing.T) {
+	verification, err := VerifyInline(nil, "message")
+	require.EqualError(t, err, "no trusted keys")
+	assert.Nil(t, verification)
+}
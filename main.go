@@ -1,13 +1,21 @@
 package main
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 
 	"github.com/coopnorge/terraform-provider-opengpg/opengpg"
 )
 
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: opengpg.Provider,
-	})
+	serverFactory, err := opengpg.ProviderServerFactory(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := tf5server.Serve("registry.terraform.io/coopnorge/opengpg", serverFactory); err != nil {
+		log.Fatal(err)
+	}
 }
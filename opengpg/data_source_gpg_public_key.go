@@ -0,0 +1,201 @@
+package opengpg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceGPGPublicKey() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGPGPublicKeyRead,
+
+		Schema: map[string]*schema.Schema{
+			"public_key": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"key_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"algorithm": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bit_length": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"creation_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expiration_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_expired": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_revoked": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"user_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"email": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"subkeys": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"fingerprint": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"algorithm": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"bit_length": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"creation_time": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"expiration_time": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"is_expired": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"is_revoked": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"can_sign": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"can_encrypt": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"can_certify": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"can_authenticate": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// formatTime formats the time in RFC 3339 format, or returns an empty string
+// for zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func dataSourceGPGPublicKeyRead(data *schema.ResourceData, _ any) error {
+	publicKey, ok := data.Get("public_key").(string)
+	if !ok {
+		return fmt.Errorf("data in property %q was not a string", "public_key")
+	}
+
+	recipient, err := encryption.GetRecipient(publicKey)
+	if err != nil {
+		return fmt.Errorf("getting public key: %w", err)
+	}
+
+	info, err := recipient.GetKeyInfo(time.Now())
+	if err != nil {
+		return fmt.Errorf("describing public key: %w", err)
+	}
+
+	userIDs := make([]any, 0, len(info.UserIDs))
+	for _, userID := range info.UserIDs {
+		userIDs = append(userIDs, map[string]any{
+			"name":  userID.Name,
+			"email": userID.Email,
+		})
+	}
+
+	subkeys := make([]any, 0, len(info.Subkeys))
+	for _, subkey := range info.Subkeys {
+		subkeys = append(subkeys, map[string]any{
+			"key_id":           subkey.KeyID,
+			"fingerprint":      subkey.Fingerprint,
+			"algorithm":        subkey.Algorithm,
+			"bit_length":       subkey.BitLength,
+			"creation_time":    formatTime(subkey.CreationTime),
+			"expiration_time":  formatTime(subkey.ExpirationTime),
+			"is_expired":       subkey.IsExpired,
+			"is_revoked":       subkey.IsRevoked,
+			"can_sign":         subkey.CanSign,
+			"can_encrypt":      subkey.CanEncrypt,
+			"can_certify":      subkey.CanCertify,
+			"can_authenticate": subkey.CanAuthenticate,
+		})
+	}
+
+	attributes := map[string]any{
+		"key_id":          info.KeyID,
+		"fingerprint":     info.Fingerprint,
+		"algorithm":       info.Algorithm,
+		"bit_length":      info.BitLength,
+		"creation_time":   formatTime(info.CreationTime),
+		"expiration_time": formatTime(info.ExpirationTime),
+		"is_expired":      info.IsExpired,
+		"is_revoked":      info.IsRevoked,
+		"user_ids":        userIDs,
+		"subkeys":         subkeys,
+	}
+
+	for key, value := range attributes {
+		if err := data.Set(key, value); err != nil {
+			return fmt.Errorf("setting %q property: %w", key, err)
+		}
+	}
+
+	data.SetId(info.Fingerprint)
+
+	return nil
+}
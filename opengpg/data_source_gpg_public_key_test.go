@@ -0,0 +1,64 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var publicKeyConfig = `
+data "opengpg_public_key" "example" {
+  public_key = var.opengpg_public_key_ecc25519
+}
+
+data "opengpg_public_key" "expired" {
+  public_key = var.opengpg_public_key_ecc25519_expired
+}
+` +
+	variableConfig("opengpg_public_key_ecc25519", "A public-key of type ECC 25519", ecc25519PublicKey) +
+	variableConfig("opengpg_public_key_ecc25519_expired", "A public-key of type ECC 25519, which expired in 2024", ecc25519ExpiredPublicKey)
+
+const publicKeyBadConfig = `
+data "opengpg_public_key" "example" {
+  public_key = "not a public key"
+}
+`
+
+func TestGPGPublicKey(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: publicKeyConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "key_id", "27076d92c444bc87"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "fingerprint", "f7a25236fede875f6308be6627076d92c444bc87"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "algorithm", "eddsa"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "bit_length", "255"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "creation_time", "2024-09-17T14:18:19Z"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "expiration_time", ""),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "is_expired", "false"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "is_revoked", "false"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "user_ids.#", "1"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "user_ids.0.name", "foobar-ecc25519"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "user_ids.0.email", "foo@bar-curve.com"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "subkeys.#", "1"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "subkeys.0.key_id", "94810cd7e7be635c"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "subkeys.0.algorithm", "ecdh"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "subkeys.0.can_encrypt", "true"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.example", "subkeys.0.can_sign", "false"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.expired", "expiration_time", "2024-09-25T09:23:42Z"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.expired", "is_expired", "true"),
+					resource.TestCheckResourceAttr("data.opengpg_public_key.expired", "subkeys.0.is_expired", "true"),
+				),
+			},
+			{
+				Config:      publicKeyBadConfig,
+				ExpectError: regexp.MustCompile(`getting public key`),
+			},
+		},
+	})
+}
@@ -0,0 +1,172 @@
+package opengpg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceGPGSignatureVerification() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGPGSignatureVerificationRead,
+
+		Schema: map[string]*schema.Schema{
+			"content": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"source"},
+			},
+			"source": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"content"},
+			},
+			"signature": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"public_keys": {
+				Type:     schema.TypeList,
+				MinItems: 1,
+				Required: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"allow_invalid": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"valid": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"signer_key_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"signer_fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"signer_email": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"signature_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"signed_content": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func verifySignature(data *schema.ResourceData, trusted []*encryption.Recipient, allowInvalid bool) (*encryption.Verification, error) {
+	signature, ok := data.Get("signature").(string)
+	if !ok {
+		return nil, fmt.Errorf("data in property %q was not a string", "signature")
+	}
+
+	// A signature which can't be read, e.g. malformed, is reported as invalid
+	// when invalid signatures are allowed.
+	allowInvalidSignature := func(verification *encryption.Verification, err error) (*encryption.Verification, error) {
+		if err != nil && allowInvalid {
+			return &encryption.Verification{Err: err}, nil
+		}
+
+		return verification, err
+	}
+
+	if source, ok := data.Get("source").(string); ok && source != "" {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("opening file %q: %w", source, err)
+		}
+		defer file.Close()
+
+		return allowInvalidSignature(encryption.VerifyDetached(trusted, file, signature))
+	}
+
+	// Empty content is still a detached signature, so check the config instead of GetOk.
+	content, diags := data.GetRawConfigAt(cty.GetAttrPath("content"))
+	if diags.HasError() {
+		return nil, fmt.Errorf("reading property %q from config: %v", "content", diags)
+	}
+
+	if !content.IsNull() {
+		if !content.IsKnown() || !content.Type().Equals(cty.String) {
+			return nil, fmt.Errorf("data in property %q was not a string", "content")
+		}
+
+		return allowInvalidSignature(encryption.VerifyDetached(trusted, strings.NewReader(content.AsString()), signature))
+	}
+
+	// Without content, signature must be an inline or cleartext signed message.
+	return allowInvalidSignature(encryption.VerifyInline(trusted, signature))
+}
+
+func dataSourceGPGSignatureVerificationRead(data *schema.ResourceData, _ any) error {
+	trusted, err := getRecipients(data)
+	if err != nil {
+		return fmt.Errorf("getting trusted keys: %w", err)
+	}
+
+	allowInvalid, ok := data.Get("allow_invalid").(bool)
+	if !ok {
+		return fmt.Errorf("data in property %q was not a bool", "allow_invalid")
+	}
+
+	verification, err := verifySignature(data, trusted, allowInvalid)
+	if err != nil {
+		return fmt.Errorf("verifying signature: %w", err)
+	}
+
+	if !verification.IsValid() && !allowInvalid {
+		return fmt.Errorf("signature is not valid: %w", verification.Err)
+	}
+
+	attributes := map[string]any{
+		"valid":              verification.IsValid(),
+		"signer_key_id":      "",
+		"signer_fingerprint": "",
+		"signer_email":       "",
+		"signature_time":     "",
+		"signed_content":     string(verification.Content),
+	}
+
+	if verification.Signer != nil {
+		attributes["signer_key_id"] = verification.Signer.GetKeyID()
+		attributes["signer_fingerprint"] = verification.Signer.GetFingerprint()
+
+		if email, ok := verification.Signer.GetUserEmail(verification.SignatureTime); ok {
+			attributes["signer_email"] = email
+		}
+	}
+
+	if !verification.SignatureTime.IsZero() {
+		attributes["signature_time"] = verification.SignatureTime.Format(time.RFC3339)
+	}
+
+	for key, value := range attributes {
+		if err := data.Set(key, value); err != nil {
+			return fmt.Errorf("setting %q property: %w", key, err)
+		}
+	}
+
+	// Calculate SHA-256 checksum of signature for ID.
+	data.SetId(sha256sum(data.Get("signature")))
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var signatureVerificationKeys = variableConfig("opengpg_private_key_curve", "A private-key of type ECC 25519, not protected by a passphrase", curvePrivateKey) +
+	variableConfig("opengpg_public_key_curve", "The public-key belonging to opengpg_private_key_curve", curvePublicKey) +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, which has not signed anything", rsa3072PublicKey)
+
+var signatureVerificationDetachedConfig = signatureVerificationKeys + `
+resource "opengpg_detached_signature" "example" {
+  content     = "This is example of GPG signed content."
+  private_key = var.opengpg_private_key_curve
+}
+
+data "opengpg_signature_verification" "example" {
+  content     = "This is example of GPG signed content."
+  signature   = opengpg_detached_signature.example.result
+  public_keys = [
+    var.opengpg_public_key_rsa,
+    var.opengpg_public_key_curve,
+  ]
+}
+`
+
+var signatureVerificationEmptyContentConfig = signatureVerificationKeys + `
+resource "opengpg_detached_signature" "example" {
+  content     = ""
+  private_key = var.opengpg_private_key_curve
+}
+
+data "opengpg_signature_verification" "example" {
+  content     = ""
+  signature   = opengpg_detached_signature.example.result
+  public_keys = [
+    var.opengpg_public_key_curve,
+  ]
+}
+`
+
+var signatureVerificationCleartextConfig = signatureVerificationKeys + `
+resource "opengpg_cleartext_signed_message" "example" {
+  content     = "This is example of GPG signed content."
+  private_key = var.opengpg_private_key_curve
+}
+
+data "opengpg_signature_verification" "example" {
+  signature   = opengpg_cleartext_signed_message.example.result
+  public_keys = [
+    var.opengpg_public_key_curve,
+  ]
+}
+`
+
+var signatureVerificationUntrustedConfig = signatureVerificationKeys + `
+resource "opengpg_detached_signature" "example" {
+  content     = "This is example of GPG signed content."
+  private_key = var.opengpg_private_key_curve
+}
+
+data "opengpg_signature_verification" "example" {
+  content     = "This is example of GPG signed content."
+  signature   = opengpg_detached_signature.example.result
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+`
+
+var signatureVerificationAllowInvalidConfig = signatureVerificationKeys + `
+resource "opengpg_detached_signature" "example" {
+  content     = "This is example of GPG signed content."
+  private_key = var.opengpg_private_key_curve
+}
+
+data "opengpg_signature_verification" "example" {
+  content       = "This is not the signed content."
+  signature     = opengpg_detached_signature.example.result
+  allow_invalid = true
+  public_keys = [
+    var.opengpg_public_key_curve,
+  ]
+}
+`
+
+var signatureVerificationMalformedConfig = signatureVerificationKeys + `
+data "opengpg_signature_verification" "example" {
+  content       = "This is example of GPG signed content."
+  signature     = "This is not a signature."
+  allow_invalid = true
+  public_keys = [
+    var.opengpg_public_key_curve,
+  ]
+}
+`
+
+func TestGPGSignatureVerificationDetached(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: signatureVerificationDetachedConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "valid", "true"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signer_key_id", "75fac23672df26de"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signer_fingerprint", "00698a165ae57b86d756c74675fac23672df26de"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signer_email", "signer@coop.no"),
+					resource.TestCheckResourceAttrSet("data.opengpg_signature_verification.example", "signature_time"),
+				),
+			},
+		},
+	})
+}
+
+func TestGPGSignatureVerificationEmptyContent(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				// Empty content is verified as detached signature, not as inline signed message.
+				Config: signatureVerificationEmptyContentConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "valid", "true"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signer_key_id", "75fac23672df26de"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signed_content", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestGPGSignatureVerificationCleartext(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: signatureVerificationCleartextConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "valid", "true"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signed_content", "This is example of GPG signed content."),
+				),
+			},
+		},
+	})
+}
+
+func TestGPGSignatureVerificationInvalid(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      signatureVerificationUntrustedConfig,
+				ExpectError: regexp.MustCompile(`signature is not valid`),
+			},
+			{
+				Config: signatureVerificationAllowInvalidConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "valid", "false"),
+				),
+			},
+			{
+				Config: signatureVerificationMalformedConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "valid", "false"),
+					resource.TestCheckResourceAttr("data.opengpg_signature_verification.example", "signer_key_id", ""),
+				),
+			},
+		},
+	})
+}
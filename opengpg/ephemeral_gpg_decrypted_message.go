@@ -0,0 +1,214 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type ephemeralGPGDecryptedMessage struct{}
+
+var (
+	_ ephemeral.EphemeralResource                   = &ephemeralGPGDecryptedMessage{}
+	_ ephemeral.EphemeralResourceWithValidateConfig = &ephemeralGPGDecryptedMessage{}
+)
+
+func newEphemeralGPGDecryptedMessage() ephemeral.EphemeralResource {
+	return &ephemeralGPGDecryptedMessage{}
+}
+
+type ephemeralGPGDecryptedMessageModel struct {
+	Message           types.String `tfsdk:"message"`
+	MessageBase64     types.String `tfsdk:"message_base64"`
+	PrivateKey        types.String `tfsdk:"private_key"`
+	Passphrase        types.String `tfsdk:"passphrase"`
+	PublicKeys        types.List   `tfsdk:"public_keys"`
+	AllowInvalid      types.Bool   `tfsdk:"allow_invalid"`
+	KeyID             types.String `tfsdk:"key_id"`
+	Result            types.String `tfsdk:"result"`
+	SignatureValid    types.Bool   `tfsdk:"signature_valid"`
+	SignerKeyID       types.String `tfsdk:"signer_key_id"`
+	SignerFingerprint types.String `tfsdk:"signer_fingerprint"`
+	SignerEmail       types.String `tfsdk:"signer_email"`
+	SignatureTime     types.String `tfsdk:"signature_time"`
+}
+
+func (e *ephemeralGPGDecryptedMessage) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_decrypted_message"
+}
+
+func (e *ephemeralGPGDecryptedMessage) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"message": schema.StringAttribute{
+				Optional: true,
+			},
+			"message_base64": schema.StringAttribute{
+				Optional: true,
+			},
+			"private_key": schema.StringAttribute{
+				Optional:  true,
+				Sensitive: true,
+			},
+			"passphrase": schema.StringAttribute{
+				Optional:  true,
+				Sensitive: true,
+			},
+			"public_keys": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"allow_invalid": schema.BoolAttribute{
+				Optional: true,
+			},
+			"key_id": schema.StringAttribute{
+				Computed: true,
+			},
+			"result": schema.StringAttribute{
+				Computed:  true,
+				Sensitive: true,
+			},
+			"signature_valid": schema.BoolAttribute{
+				Computed: true,
+			},
+			"signer_key_id": schema.StringAttribute{
+				Computed: true,
+			},
+			"signer_fingerprint": schema.StringAttribute{
+				Computed: true,
+			},
+			"signer_email": schema.StringAttribute{
+				Computed: true,
+			},
+			"signature_time": schema.StringAttribute{
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (e *ephemeralGPGDecryptedMessage) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data ephemeralGPGDecryptedMessageModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Unknown values are validated again, once they are known.
+	if data.Message.IsUnknown() || data.MessageBase64.IsUnknown() || data.PrivateKey.IsUnknown() || data.Passphrase.IsUnknown() || data.PublicKeys.IsUnknown() {
+		return
+	}
+
+	if data.Message.IsNull() == data.MessageBase64.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("message"),
+			"Invalid attribute combination",
+			`Exactly one of "message" or "message_base64" must be set.`,
+		)
+	}
+
+	if data.PrivateKey.IsNull() && data.Passphrase.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("private_key"),
+			"Invalid attribute combination",
+			`At least one of "private_key" or "passphrase" must be set.`,
+		)
+	}
+}
+
+func (e *ephemeralGPGDecryptedMessage) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data ephemeralGPGDecryptedMessageModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	publicKeys := []string{}
+
+	resp.Diagnostics.Append(data.PublicKeys.ElementsAs(ctx, &publicKeys, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	decryption, err := decryptMessage(&data, publicKeys)
+	if err != nil {
+		resp.Diagnostics.AddError("Decrypting message", err.Error())
+		return
+	}
+
+	data.Result = types.StringValue(string(decryption.Content))
+	data.SignerKeyID = types.StringValue(decryption.SignerKeyID)
+	data.SignatureValid = types.BoolValue(false)
+	data.SignerFingerprint = types.StringValue("")
+	data.SignerEmail = types.StringValue("")
+	data.SignatureTime = types.StringValue("")
+
+	if verification := decryption.Verification; verification != nil {
+		if !verification.IsValid() && !data.AllowInvalid.ValueBool() {
+			resp.Diagnostics.AddError("Verifying signature", fmt.Sprintf("signature is not valid: %v", verification.Err))
+			return
+		}
+
+		data.SignatureValid = types.BoolValue(verification.IsValid())
+
+		if verification.Signer != nil {
+			data.SignerFingerprint = types.StringValue(verification.Signer.GetFingerprint())
+
+			if email, ok := verification.Signer.GetUserEmail(verification.SignatureTime); ok {
+				data.SignerEmail = types.StringValue(email)
+			}
+		}
+
+		if !verification.SignatureTime.IsZero() {
+			data.SignatureTime = types.StringValue(verification.SignatureTime.Format(time.RFC3339))
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+// decryptMessage decrypts the message with the private key, or with the
+// passphrase, if no private key is given. The passphrase unlocks the private
+// key otherwise.
+func decryptMessage(data *ephemeralGPGDecryptedMessageModel, publicKeys []string) (*encryption.Decryption, error) {
+	trusted, err := encryption.GetRecipients(publicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("getting trusted keys: %w", err)
+	}
+
+	options := encryption.DecryptionOptions{
+		Trusted: trusted,
+		Format:  encryption.OutputFormatArmored,
+	}
+
+	message := data.Message.ValueString()
+	if !data.MessageBase64.IsNull() {
+		message = data.MessageBase64.ValueString()
+		options.Format = encryption.OutputFormatBinaryBase64
+	}
+
+	data.KeyID = types.StringValue("")
+
+	if data.PrivateKey.IsNull() {
+		options.Passphrase = data.Passphrase.ValueString()
+
+		return encryption.DecryptMessageWithOptions(message, options)
+	}
+
+	options.Decrypter, err = encryption.GetDecrypter(data.PrivateKey.ValueString(), data.Passphrase.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("getting decryption key: %w", err)
+	}
+
+	data.KeyID = types.StringValue(options.Decrypter.GetKeyID())
+
+	return encryption.DecryptMessageWithOptions(message, options)
+}
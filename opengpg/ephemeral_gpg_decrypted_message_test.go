@@ -0,0 +1,176 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var decryptedMessageKeys = `
+variable "opengpg_encrypted_message" {
+  description = "A message encrypted to opengpg_private_key_rsa, and signed by opengpg_public_key_curve"
+  default = <<EOF
+-----BEGIN PGP MESSAGE-----
+
+wcDMA35a5vzCJvorAQwAwh6cGgTa4F/IpKc5/MbjHfSUlPap1wxpetvCS96n/kuS
+8hG8fIO5btz11QeHJLy2wzETkBzr6onsNkQlAxe24+n/tFh1fdT0/eR6I990qjR3
+XGrEb/IGrhs+YVH7Um9NifqAqb4sn+3QavW7rZBAAgMh5OGDGvlT/9uihpjKO3bC
+Qxcikd1I8EdG8ymD88twaxdk43R9dgh1jPj/Fu74TZw+5vzVdpBtj68lTwKJ4+Ga
+Uks2i9n8YLxEwHBvu8cWnXwznOJnhOfTwx1mGLSPEfTFuXtGnZ4BwAEwUataridw
+YwiJcAUq+jd9bF1YKxr/kTElTUVzdtN5jsC/2llh8mnQWJYx28PDaa/Kfz2hptnv
+hZItWUApUjOTPGK2UlKjLIAuC+bmEeN81d/rPDIyjZcThgLlpYyuc3XyP+wlzZHE
+MAWMlwonB4VBylZk9gMAzMcvXdnQEvYs0A0e/9q0wjxzzpsYU6EbWTogIs8TP6HJ
+L8DEGGQACSFnHOedTf/j0sBuAYWxCQXGvFv+jxPXsdVVYKdPBv3HkDHyh6gCmYzR
+T34th3Nfn3q2PKQJczfhoDqIBq9C1X8XYa8jn5XIK3Sp/C6+Ya6twP90uU9PWCQ9
+t1so4cGwDam4GbLcsNkyqLeU10eNUktST7KPSp4OOOhPRkSohJH/IHuvM25qtPAh
+ztaajBl65TE6QMh3Q7ldPElv3pNFRlDa1qqY1MKy373YYFt/P5bISmOrM0wpWzu5
+06qn4v2xedjdkaEnxLB1TYR19DD9fOsc7lOo/MoLwAlp+bmb8CSPqJe5rdBZQWpU
+svTV9aYKTgSkFr7xcazsmAwVfHW8qZ4kcT4Ar0v0fQOqvc75Dpv3S/BWG9qZyw4h
+uIb5ZZI6PqvhQcKcE8aIWUXeRJZb26tPEJ5wRgaRlgU=
+=5acB
+-----END PGP MESSAGE-----
+EOF
+}
+` +
+	variableConfig("opengpg_private_key_rsa", "A private-key of type RSA 3072, protected by passphrase", rsa3072PrivateKey) +
+	variableConfig("opengpg_public_key_curve", "A public-key of type ECC 25519, which signed opengpg_encrypted_message", curvePublicKey) +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, which has not signed anything", rsa3072PublicKey)
+
+var decryptedMessageConfig = decryptedMessageKeys + `
+ephemeral "opengpg_decrypted_message" "example" {
+  message     = var.opengpg_encrypted_message
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "correct horse battery staple"
+  public_keys = [
+    var.opengpg_public_key_curve,
+  ]
+}
+`
+
+var decryptedMessageUntrustedConfig = decryptedMessageKeys + `
+ephemeral "opengpg_decrypted_message" "example" {
+  message     = var.opengpg_encrypted_message
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "correct horse battery staple"
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+`
+
+// decryptedMessageUnknownKeysConfig trusts a key, which is only known after
+// apply, so public_keys are unknown during validation.
+var decryptedMessageUnknownKeysConfig = decryptedMessageKeys + `
+resource "opengpg_private_key" "trusted" {
+  user_ids {
+    email = "trusted@coop.no"
+  }
+}
+
+ephemeral "opengpg_decrypted_message" "example" {
+  message       = var.opengpg_encrypted_message
+  private_key   = var.opengpg_private_key_rsa
+  passphrase    = "correct horse battery staple"
+  allow_invalid = true
+  public_keys = [
+    opengpg_private_key.trusted.public_key_armored,
+  ]
+}
+`
+
+var decryptedMessageWrongPassphraseConfig = decryptedMessageKeys + `
+ephemeral "opengpg_decrypted_message" "example" {
+  message     = var.opengpg_encrypted_message
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "wrong"
+}
+`
+
+var decryptedMessageBinaryConfig = decryptedMessageKeys + `
+ephemeral "opengpg_decrypted_message" "example" {
+  # "hello world" encrypted to opengpg_private_key_rsa, in binary format.
+  message_base64 = "wcDMA35a5vzCJvorAQv9FOzxdwj3c4nfIGneWFMiapiIlfAw9s8nLWDiLiexS1xMMQI+hWVqK0cj11v8GHhfMu3j8exeoZrRG9J05mxp49ipjDhQPk2+I+mKsnkNfJKJnJFOcvsY9Px+V4EY2eSVvBTA4I2MIQlV05FPXbDBvNOEZB00nJz5tmkMTckxAvqA1sT30OFqO8HSGSt3pANSn6nxpvSh73OAdrflLmgBtr/EsBnKvG6VUI+R/W0w+Dk+ZGhj2PvA03oNvlKXuSjDuX0VNRYaUWy3kbCs9wy536Mtz+PrE26Xs0eWlAKyTFru/JEsel4SiE/ByTvwkHEVUOiS9iZ8U+QHSEZXkRcvfSZiCLlaSHXUZm0xDpYuw7mitmVSE1BkstSVfLmLuTfNHIGqLvdeIAAgOjtinTKFer/kCjp+IF/onXhrsmM8DjWZ7kvHSzxGblwm1EFUFXddo06m8XgSMDCG4XNbQVqwL7qGlScRxrScZu1Uso5/Fs3yFiUSJWAq2rzlgQwC0a+T0jwBwx27AzBBl/YKGz234R0N2uKAnjaUJfQVsvrwxUhiIWknNeJluVuel+/1e+4Clkw7TAPNN5pAt2sGf9g="
+  private_key    = var.opengpg_private_key_rsa
+  passphrase     = "correct horse battery staple"
+}
+`
+
+var decryptedMessagePassphraseConfig = `
+ephemeral "opengpg_decrypted_message" "example" {
+  # "hello world" encrypted with the passphrase only.
+  message    = <<EOF
+-----BEGIN PGP MESSAGE-----
+
+wy4ECQMIgP7DY+sVT9LgLuoHBHlEboO7vIavWQGvdqv0m9qa8HU1ZZOiG7wOggDO
+0jwBnJ8U+RwiTSjSX6wbGo15OoS1RpjOYVPa/noPo+wY6uOEk+HgIuV5xzkLLYu3
+OoNNIl99U2T3A4qBljM=
+=oHWG
+-----END PGP MESSAGE-----
+EOF
+  passphrase = "correct horse battery staple"
+}
+`
+
+var decryptedMessageBothMessagesConfig = decryptedMessageKeys + `
+ephemeral "opengpg_decrypted_message" "example" {
+  message        = var.opengpg_encrypted_message
+  message_base64 = "wcDMA35a5vzCJvor"
+  private_key    = var.opengpg_private_key_rsa
+  passphrase     = "correct horse battery staple"
+}
+`
+
+var decryptedMessageNoKeyConfig = decryptedMessageKeys + `
+ephemeral "opengpg_decrypted_message" "example" {
+  message = var.opengpg_encrypted_message
+}
+`
+
+func TestGPGDecryptedMessage(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: decryptedMessageConfig,
+			},
+			{
+				Config:      decryptedMessageUntrustedConfig,
+				ExpectError: regexp.MustCompile(`signature is not valid`),
+			},
+			{
+				Config: decryptedMessageUnknownKeysConfig,
+			},
+			{
+				Config:      decryptedMessageWrongPassphraseConfig,
+				ExpectError: regexp.MustCompile(`unlocking private key`),
+			},
+		},
+	})
+}
+
+func TestGPGDecryptedMessageBinaryAndPassphrase(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: decryptedMessageBinaryConfig,
+			},
+			{
+				Config: decryptedMessagePassphraseConfig,
+			},
+			{
+				Config:      decryptedMessageBothMessagesConfig,
+				ExpectError: regexp.MustCompile(`Exactly one of "message" or "message_base64"`),
+			},
+			{
+				Config:      decryptedMessageNoKeyConfig,
+				ExpectError: regexp.MustCompile(`At least one of "private_key" or "passphrase"`),
+			},
+		},
+	})
+}
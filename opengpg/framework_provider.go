@@ -0,0 +1,141 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+)
+
+// frameworkProvider is the part of terraform-provider-opengpg, which is
+// implemented with terraform-plugin-framework. It is served together with the
+// SDK v2 provider, and contains features not supported by SDK v2, like
+// ephemeral resources and provider functions.
+type frameworkProvider struct{}
+
+var (
+	_ provider.ProviderWithEphemeralResources = &frameworkProvider{}
+	_ provider.ProviderWithFunctions          = &frameworkProvider{}
+)
+
+// NewFrameworkProvider returns the part of terraform-provider-opengpg, which is
+// implemented with terraform-plugin-framework.
+func NewFrameworkProvider() provider.Provider {
+	return &frameworkProvider{}
+}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "opengpg"
+}
+
+// Schema must be identical to the schema of the SDK v2 provider, otherwise
+// they can not be served together.
+func (p *frameworkProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"state_hash_key": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: stateHashKeyDescription,
+			},
+			"default_public_keys": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: defaultPublicKeysDescription,
+			},
+			"profile": schema.StringAttribute{
+				Optional:    true,
+				Description: profileDescription,
+			},
+			"evaluation_time": schema.StringAttribute{
+				Optional:    true,
+				Description: evaluationTimeDescription,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"algorithm_policy": schema.ListNestedBlock{
+				Description: algorithmPolicyDescription,
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"min_rsa_bits": schema.Int64Attribute{Optional: true},
+						"forbidden_algorithms": schema.ListAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"forbid_sha1": schema.BoolAttribute{Optional: true},
+						"action":      schema.StringAttribute{Optional: true},
+					},
+				},
+			},
+			"recipient_policy": schema.ListNestedBlock{
+				Description: recipientPolicyDescription,
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"min_key_validity":        schema.StringAttribute{Optional: true},
+						"min_key_validity_action": schema.StringAttribute{Optional: true},
+						"invalid_key_action":      schema.StringAttribute{Optional: true},
+						"allowed_recipient_fingerprints": schema.ListAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+						"required_recipient_fingerprints": schema.ListAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (p *frameworkProvider) Configure(_ context.Context, _ provider.ConfigureRequest, _ *provider.ConfigureResponse) {
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (p *frameworkProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		newEphemeralGPGDecryptedMessage,
+	}
+}
+
+func (p *frameworkProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		newKeyIDFunction,
+		newFingerprintFunction,
+		newPrimaryEmailFunction,
+		newIsExpiredFunction,
+	}
+}
+
+// ProviderServerFactory returns a factory of provider servers, which serve both
+// the SDK v2 provider and the terraform-plugin-framework provider.
+func ProviderServerFactory(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	providers := []func() tfprotov5.ProviderServer{
+		Provider().GRPCProvider,
+		providerserver.NewProtocol5(NewFrameworkProvider()),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, fmt.Errorf("creating mux server: %w", err)
+	}
+
+	return muxServer.ProviderServer, nil
+}
@@ -0,0 +1,40 @@
+package opengpg
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+type fingerprintFunction struct{}
+
+var _ function.Function = &fingerprintFunction{}
+
+func newFingerprintFunction() function.Function {
+	return &fingerprintFunction{}
+}
+
+func (f *fingerprintFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "fingerprint"
+}
+
+func (f *fingerprintFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Returns the fingerprint of a public key",
+		Description: "Returns the fingerprint of the primary key of a GPG public key, as lowercase hexadecimal characters.",
+		Parameters: []function.Parameter{
+			publicKeyParameter(),
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *fingerprintFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	recipient, funcErr := getRecipientArgument(ctx, req, 0)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, recipient.GetFingerprint())
+}
@@ -0,0 +1,38 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var fingerprintFunctionConfig = functionPublicKeys + `
+output "fingerprint" {
+  value = provider::opengpg::fingerprint(var.opengpg_public_key_ecc25519)
+}
+`
+
+const fingerprintFunctionBadConfig = functionRequiredProviders + `
+output "fingerprint" {
+  value = provider::opengpg::fingerprint("not a public key")
+}
+`
+
+func TestFingerprintFunction(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fingerprintFunctionConfig,
+				Check:  resource.TestCheckOutput("fingerprint", "f7a25236fede875f6308be6627076d92c444bc87"),
+			},
+			{
+				Config:      fingerprintFunctionBadConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`Invalid value for "public_key" parameter: decoding public key`)),
+			},
+		},
+	})
+}
@@ -0,0 +1,59 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+type isExpiredFunction struct{}
+
+var _ function.Function = &isExpiredFunction{}
+
+func newIsExpiredFunction() function.Function {
+	return &isExpiredFunction{}
+}
+
+func (f *isExpiredFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_expired"
+}
+
+func (f *isExpiredFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Returns whether a public key is expired",
+		Description: "Returns whether a GPG public key is expired at the given time.",
+		Parameters: []function.Parameter{
+			publicKeyParameter(),
+			function.StringParameter{
+				Name:        "timestamp",
+				Description: "Time in RFC 3339 format, e.g. the result of timestamp().",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *isExpiredFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	recipient, funcErr := getRecipientArgument(ctx, req, 0)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	var timestamp string
+
+	resp.Error = req.Arguments.GetArgument(ctx, 1, &timestamp)
+	if resp.Error != nil {
+		return
+	}
+
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("parsing timestamp: %s", err))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, recipient.IsExpired(t))
+}
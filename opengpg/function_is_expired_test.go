@@ -0,0 +1,60 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var isExpiredFunctionConfig = functionPublicKeys + `
+output "is_expired" {
+  value = provider::opengpg::is_expired(var.opengpg_public_key_ecc25519, "2025-01-01T00:00:00Z")
+}
+
+output "is_expired_before_expiry" {
+  value = provider::opengpg::is_expired(var.opengpg_public_key_ecc25519_expired, "2024-09-25T00:00:00Z")
+}
+
+output "is_expired_after_expiry" {
+  value = provider::opengpg::is_expired(var.opengpg_public_key_ecc25519_expired, "2024-09-25T16:00:00+02:00")
+}
+`
+
+const isExpiredFunctionBadKeyConfig = functionRequiredProviders + `
+output "is_expired" {
+  value = provider::opengpg::is_expired("not a public key", "2025-01-01T00:00:00Z")
+}
+`
+
+var isExpiredFunctionBadTimestampConfig = functionPublicKeys + `
+output "is_expired" {
+  value = provider::opengpg::is_expired(var.opengpg_public_key_ecc25519, "2025-01-01")
+}
+`
+
+func TestIsExpiredFunction(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: isExpiredFunctionConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckOutput("is_expired", "false"),
+					resource.TestCheckOutput("is_expired_before_expiry", "false"),
+					resource.TestCheckOutput("is_expired_after_expiry", "true"),
+				),
+			},
+			{
+				Config:      isExpiredFunctionBadKeyConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`Invalid value for "public_key" parameter: decoding public key`)),
+			},
+			{
+				Config:      isExpiredFunctionBadTimestampConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`Invalid value for "timestamp" parameter: parsing timestamp`)),
+			},
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+type keyIDFunction struct{}
+
+var _ function.Function = &keyIDFunction{}
+
+func newKeyIDFunction() function.Function {
+	return &keyIDFunction{}
+}
+
+func (f *keyIDFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "key_id"
+}
+
+func (f *keyIDFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Returns the key ID of a public key",
+		Description: "Returns the key ID of the primary key of a GPG public key, as 16 lowercase hexadecimal characters.",
+		Parameters: []function.Parameter{
+			publicKeyParameter(),
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *keyIDFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	recipient, funcErr := getRecipientArgument(ctx, req, 0)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, recipient.GetKeyID())
+}
+
+// publicKeyParameter is the parameter of the functions, which inspect a public key.
+func publicKeyParameter() function.StringParameter {
+	return function.StringParameter{
+		Name:        "public_key",
+		Description: "GPG public key in ASCII-armored format.",
+	}
+}
+
+// getRecipientArgument decodes the public key in the argument at the position.
+// Malformed keys are reported as errors of the argument.
+func getRecipientArgument(ctx context.Context, req function.RunRequest, position int) (*encryption.Recipient, *function.FuncError) {
+	var publicKey string
+
+	if funcErr := req.Arguments.GetArgument(ctx, position, &publicKey); funcErr != nil {
+		return nil, funcErr
+	}
+
+	recipient, err := encryption.GetRecipient(publicKey)
+	if err != nil {
+		return nil, function.NewArgumentFuncError(int64(position), fmt.Sprintf("decoding public key: %s", err))
+	}
+
+	return recipient, nil
+}
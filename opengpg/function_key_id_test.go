@@ -0,0 +1,52 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// Provider functions can only be called from modules, which declare the provider.
+const functionRequiredProviders = `
+terraform {
+  required_providers {
+    opengpg = {}
+  }
+}
+`
+
+// functionPublicKeys are the public keys inspected in tests of provider functions.
+var functionPublicKeys = functionRequiredProviders +
+	variableConfig("opengpg_public_key_ecc25519", "A public-key of type ECC 25519", ecc25519PublicKey) +
+	variableConfig("opengpg_public_key_ecc25519_expired", "A public-key of type ECC 25519, which expired in 2024", ecc25519ExpiredPublicKey)
+
+var keyIDFunctionConfig = functionPublicKeys + `
+output "key_id" {
+  value = provider::opengpg::key_id(var.opengpg_public_key_ecc25519)
+}
+`
+
+const keyIDFunctionBadConfig = functionRequiredProviders + `
+output "key_id" {
+  value = provider::opengpg::key_id("not a public key")
+}
+`
+
+func TestKeyIDFunction(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: keyIDFunctionConfig,
+				Check:  resource.TestCheckOutput("key_id", "27076d92c444bc87"),
+			},
+			{
+				Config:      keyIDFunctionBadConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`Invalid value for "public_key" parameter: decoding public key`)),
+			},
+		},
+	})
+}
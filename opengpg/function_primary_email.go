@@ -0,0 +1,50 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+type primaryEmailFunction struct{}
+
+var _ function.Function = &primaryEmailFunction{}
+
+func newPrimaryEmailFunction() function.Function {
+	return &primaryEmailFunction{}
+}
+
+func (f *primaryEmailFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "primary_email"
+}
+
+func (f *primaryEmailFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Returns the email of the primary user ID of a public key",
+		Description: "Returns the email of the primary user ID of a GPG public key.",
+		Parameters: []function.Parameter{
+			publicKeyParameter(),
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *primaryEmailFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	recipient, funcErr := getRecipientArgument(ctx, req, 0)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	// Zero time ignores expiration of user IDs, so the result only depends on
+	// the key, and stays the same between plan and apply.
+	email, ok := recipient.GetUserEmail(time.Time{})
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("public key %s has no primary user ID", recipient.GetKeyID()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, email)
+}
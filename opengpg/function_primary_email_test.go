@@ -0,0 +1,45 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var primaryEmailFunctionConfig = functionPublicKeys + `
+output "primary_email" {
+  value = provider::opengpg::primary_email(var.opengpg_public_key_ecc25519)
+}
+
+output "primary_email_expired" {
+  value = provider::opengpg::primary_email(var.opengpg_public_key_ecc25519_expired)
+}
+`
+
+const primaryEmailFunctionBadConfig = functionRequiredProviders + `
+output "primary_email" {
+  value = provider::opengpg::primary_email("not a public key")
+}
+`
+
+func TestPrimaryEmailFunction(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: protoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: primaryEmailFunctionConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckOutput("primary_email", "foo@bar-curve.com"),
+					resource.TestCheckOutput("primary_email_expired", "foo@coop.no"),
+				),
+			},
+			{
+				Config:      primaryEmailFunctionBadConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`Invalid value for "public_key" parameter: decoding public key`)),
+			},
+		},
+	})
+}
@@ -1,15 +1,285 @@
 package opengpg
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// stateHashKeyDescription is shared by schemas of both SDK v2 and framework
+// providers, which must be identical.
+const stateHashKeyDescription = "Key of HMAC-SHA-256, which is kept in state instead of plain SHA-256 of secret properties, " +
+	"like content of encrypted messages and passphrases. Can also be set with the OPENGPG_STATE_HASH_KEY environment variable."
+
+// Descriptions below are shared by schemas of both SDK v2 and framework providers.
+const (
+	defaultPublicKeysDescription = "GPG public keys in ASCII-armored format, which every opengpg_encrypted_message is encrypted with, " +
+		"in addition to its own public_keys."
+	profileDescription        = "Profile, which selects algorithms of encrypted messages. One of default, rfc4880 or rfc9580."
+	evaluationTimeDescription = "Point in time in RFC 3339 format, which is used instead of the current time to check validity of public keys, " +
+		"and as the time of encryption and signature."
+	algorithmPolicyDescription = "Restrictions of algorithms of public keys, which every opengpg_encrypted_message is encrypted with."
+	recipientPolicyDescription = "Defaults of min_key_validity, min_key_validity_action and invalid_key_action " +
+		"for every opengpg_encrypted_message, which does not set them, and fingerprints of allowed and required recipients."
 )
 
+// providerMeta is the configuration of the provider, which is passed to resources.
+type providerMeta struct {
+	stateHashKey []byte
+	// defaultRecipients are added to recipients of every encrypted message.
+	defaultRecipients []*encryption.Recipient
+	profile           string
+	// evaluationTime replaces the current time, if set.
+	evaluationTime  time.Time
+	recipientPolicy recipientPolicy
+	algorithmPolicy encryption.AlgorithmPolicy
+	// warnWeakAlgorithms only warns about keys violating algorithmPolicy.
+	warnWeakAlgorithms bool
+}
+
+// getProviderMeta returns the configuration of the provider. Unconfigured
+// provider, e.g. during validation, has the default configuration.
+func getProviderMeta(meta any) *providerMeta {
+	if config, ok := meta.(*providerMeta); ok && config != nil {
+		return config
+	}
+
+	return &providerMeta{}
+}
+
+// withDefaultRecipients returns the recipients together with the default
+// recipients, which are not among them already.
+func (m *providerMeta) withDefaultRecipients(recipients []*encryption.Recipient) []*encryption.Recipient {
+	all := append([]*encryption.Recipient{}, recipients...)
+
+	fingerprints := map[string]bool{}
+	for _, recipient := range recipients {
+		fingerprints[recipient.GetFingerprint()] = true
+	}
+
+	for _, recipient := range m.defaultRecipients {
+		if !fingerprints[recipient.GetFingerprint()] {
+			fingerprints[recipient.GetFingerprint()] = true
+			all = append(all, recipient)
+		}
+	}
+
+	return all
+}
+
+// now returns "evaluation_time", or the current time, if it is not set.
+func (m *providerMeta) now() time.Time {
+	if !m.evaluationTime.IsZero() {
+		return m.evaluationTime
+	}
+
+	return time.Now()
+}
+
 // Provider exports terraform-provider-opengpg, which can be used in tests
 // for other providers.
 func Provider() *schema.Provider {
-	return &schema.Provider{
-		ResourcesMap: map[string]*schema.Resource{
-			"opengpg_encrypted_message": resourceGPGEncryptedMessage(),
+	p := &schema.Provider{}
+
+	p.Schema = map[string]*schema.Schema{
+		"state_hash_key": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			DefaultFunc:  schema.EnvDefaultFunc("OPENGPG_STATE_HASH_KEY", nil),
+			ValidateFunc: validation.StringIsNotEmpty,
+			Description:  stateHashKeyDescription,
+		},
+		"default_public_keys": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: defaultPublicKeysDescription,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+		},
+		"profile": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice(encryption.Profiles(), false),
+			Description:  profileDescription,
+		},
+		"evaluation_time": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.IsRFC3339Time,
+			Description:  evaluationTimeDescription,
+		},
+		"recipient_policy": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: recipientPolicyDescription,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"min_key_validity": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validateDuration,
+					},
+					"min_key_validity_action": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validation.StringInSlice(keyValidityActions(), false),
+					},
+					"invalid_key_action": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validation.StringInSlice(invalidKeyActions(), false),
+					},
+					"allowed_recipient_fingerprints": {
+						Type:     schema.TypeList,
+						Optional: true,
+						Elem: &schema.Schema{
+							Type:         schema.TypeString,
+							ValidateFunc: validateFingerprint,
+						},
+					},
+					"required_recipient_fingerprints": {
+						Type:     schema.TypeList,
+						Optional: true,
+						Elem: &schema.Schema{
+							Type:         schema.TypeString,
+							ValidateFunc: validateFingerprint,
+						},
+					},
+				},
+			},
+		},
+		"algorithm_policy": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: algorithmPolicyDescription,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"min_rsa_bits": {
+						Type:         schema.TypeInt,
+						Optional:     true,
+						ValidateFunc: validation.IntAtLeast(0),
+					},
+					"forbidden_algorithms": {
+						Type:     schema.TypeList,
+						Optional: true,
+						Elem: &schema.Schema{
+							Type:         schema.TypeString,
+							ValidateFunc: validation.StringInSlice(encryption.Algorithms(), false),
+						},
+					},
+					"forbid_sha1": {
+						Type:     schema.TypeBool,
+						Optional: true,
+					},
+					// Values are the same as of "min_key_validity_action".
+					"action": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validation.StringInSlice(keyValidityActions(), false),
+					},
+				},
+			},
 		},
 	}
+	p.ConfigureFunc = providerConfigure
+	p.ResourcesMap = map[string]*schema.Resource{
+		// Validation of the config gets no provider configuration, so it is read from the provider.
+		"opengpg_encrypted_message":        resourceGPGEncryptedMessage(p.Meta),
+		"opengpg_signed_message":           resourceGPGSignedMessage(),
+		"opengpg_detached_signature":       resourceGPGDetachedSignature(),
+		"opengpg_cleartext_signed_message": resourceGPGCleartextSignedMessage(),
+		"opengpg_private_key":              resourceGPGPrivateKey(),
+	}
+	p.DataSourcesMap = map[string]*schema.Resource{
+		"opengpg_signature_verification": dataSourceGPGSignatureVerification(),
+		"opengpg_public_key":             dataSourceGPGPublicKey(),
+	}
+
+	return p
+}
+
+func providerConfigure(data *schema.ResourceData) (any, error) {
+	meta := &providerMeta{}
+
+	if stateHashKey, ok := data.GetOk("state_hash_key"); ok {
+		stateHashKeyString, ok := stateHashKey.(string)
+		if !ok {
+			return nil, fmt.Errorf("data in property %q was not a string", "state_hash_key")
+		}
+
+		meta.stateHashKey = []byte(stateHashKeyString)
+	}
+
+	defaultPublicKeys, err := getStringList(data, "default_public_keys")
+	if err != nil {
+		return nil, err
+	}
+
+	meta.defaultRecipients, err = encryption.GetRecipients(defaultPublicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("getting default public keys: %w", err)
+	}
+
+	profile, ok := data.Get("profile").(string)
+	if !ok {
+		return nil, fmt.Errorf("data in property %q was not a string", "profile")
+	}
+
+	meta.profile = profile
+
+	evaluationTime, ok := data.Get("evaluation_time").(string)
+	if !ok {
+		return nil, fmt.Errorf("data in property %q was not a string", "evaluation_time")
+	}
+
+	if evaluationTime != "" {
+		meta.evaluationTime, err = time.Parse(time.RFC3339, evaluationTime)
+		if err != nil {
+			return nil, fmt.Errorf("parsing property %q: %w", "evaluation_time", err)
+		}
+	}
+
+	meta.recipientPolicy, err = getProviderRecipientPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("getting recipient policy: %w", err)
+	}
+
+	meta.algorithmPolicy, meta.warnWeakAlgorithms, err = getAlgorithmPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("getting algorithm policy: %w", err)
+	}
+
+	return meta, nil
+}
+
+// getStringList returns the list of strings in the property.
+func getStringList(data *schema.ResourceData, key string) ([]string, error) {
+	// Properties nested in blocks are nil, when they are not set.
+	if data.Get(key) == nil {
+		return nil, nil
+	}
+
+	valuesAny, ok := data.Get(key).([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected type %T on key %q, got %T", []any{}, key, data.Get(key))
+	}
+
+	values := make([]string, 0, len(valuesAny))
+	for i, v := range valuesAny {
+		value, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected type string on %q (idx %d), got %T", key, i, v)
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
 }
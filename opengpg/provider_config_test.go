@@ -0,0 +1,459 @@
+package opengpg_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var providerDefaultPublicKeysConfig = `
+provider "opengpg" {
+  %s
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content    = "This is example of GPG encrypted message."
+  passphrase = "correct horse battery staple"
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey) +
+	variableConfig("opengpg_public_key_ecc25519_expired", "A public-key of type ECC 25519, which expired in 2024", ecc25519ExpiredPublicKey)
+
+var providerDefaultPublicKeysOnlyConfig = `
+provider "opengpg" {
+  default_public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content = "This is example of GPG encrypted message."
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const providerRecipientPolicyConfig = `
+provider "opengpg" {
+  recipient_policy {
+    min_key_validity        = "2160h"
+    min_key_validity_action = %q
+  }
+}
+
+resource "opengpg_private_key" "example" {
+  expiration_days = 30
+
+  user_ids {
+    email = "example@coop.no"
+  }
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  public_keys = [
+    opengpg_private_key.example.public_key_armored,
+  ]
+  %s
+}
+`
+
+var providerRecipientAllowlistConfig = `
+provider "opengpg" {
+  default_public_keys = %s
+
+  recipient_policy {
+    allowed_recipient_fingerprints  = %s
+    required_recipient_fingerprints = %s
+  }
+}
+
+resource "opengpg_private_key" "example" {
+  user_ids {
+    email = "example@coop.no"
+  }
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  public_keys = %s
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+var providerAlgorithmPolicyConfig = `
+provider "opengpg" {
+  default_public_keys = %s
+
+  algorithm_policy {
+    min_rsa_bits         = 3072
+    forbidden_algorithms = ["dsa", "elgamal"]
+    forbid_sha1          = true
+    action               = %q
+  }
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  public_keys = %s
+}
+
+variable "opengpg_public_key_rsa_2048" {
+  description = "A public-key of type RSA 2048, with RSA 2048 encryption subkey"
+  default = <<EOF
+-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mQENBGrTj7UBCADORmiMcaemgL2uXb57gHLOMWA2gmp6yz+zU82PG6VuLwVPSPiy
+8+bWdGUSKs1bRYImzuOD7lHQC9BWG1IeUfkgzOR7FZFPltAgvaXTdBCT0q91fS4G
+3AvP32x/M/ccExJj5ZzRwfXa0sdgVwZbm5DuTASrGj9Lwo/n0ipKaRNRHb8ZfzN+
+1kyyPcFtdn5qQfDdxsVScG2S+yRl+UTByJCIubRgz/e5ZLBVtz4snu4Vdaiz4J6e
+FkyGi5Tt5YVliu4m5HrWAeGQz90KcbkUFd+ZdP5E/FRy+w6Z7vPDKgZtIvChy/hT
+9sooxuhqkbGkA7ZLf5FSsWGgwUmtQwhh2c9DABEBAAG0G1dlYWsgUlNBIDx3ZWFr
+LXJzYUBjb29wLm5vPokBTgQTAQoAOBYhBBYd0hCghJWTBDQSnvsj6iNLhMUBBQJq
+04+1AhsDBQsJCAcCBhUKCQgLAgQWAgMBAh4BAheAAAoJEPsj6iNLhMUBKh4IAL2F
+YpJ09JO2tEVdZ0cENhhIB+6A3NiE4ic2mpUOq2cRWS6eJ4YzIOkt1+Cdn8vcQCOK
+9iMEZmhY1wINn6fKslU76csGXoXRpxhHPHcc+7ecwqv83FpKlxmVSaWlA+EkpJuS
+LpvyOxGcFAWmYz/YP5e8lvjby5MIIVoYZohemf1rIoJQ0WUvZ3kQhjyrAH0Gwi1F
+9kSwgsIVk/YZv9yWTR3xuxfNdNVAVzhBXJk5GKQ8f1LbrAjTzmkXR1G6k2S+osyD
+x/+jFa0shwZZbHTnRzu6PwtblTR+7zFTFYREYvODYz4MkyBBLbrz+EMSTj8z16At
+KCi8xn+1FyApvcY9j3K5AQ0EatOPugEIAK8k4V/4+Gsl3MBWWIMP6/gFOATgIiVs
+o48TATOU0ujVBf8htfu2OgiibZz+AwC71vCOe456SeuBXUE6q3DyjeAbKwzFnI6H
+dpBq1Xe9RZ82IAMO6d6F9YwzgX6jFiHiRiReqNFILQpecGfT6sncVKHoMHDbCrPV
+laxsiZMf4c/KIaOwOBoZsD0IPWTMXwJwPNA+LNTbKbgOTAuP1oEhFScWlmGjxbbd
+C6ByiyfKuFNcx/FbCNEnWvlclnOTPJVEIABMg9c8bOWMF1/CeyEw8G9zupWvAl7P
+J8ax0UhQgoHJllqUxptdoJzNfA4ETMFMu2VhH3UQ6HfUO2c+J0g9fPsAEQEAAYkB
+NgQYAQoAIBYhBBYd0hCghJWTBDQSnvsj6iNLhMUBBQJq04+6AhsMAAoJEPsj6iNL
+hMUB02YIAL4rWpPk+aj55ICDI7GXM013OepVFRgSeD75KUMoF+4bSYMrTv15nd6X
+OKnDOEJdsJBoTQKx6oPZc953s80Woqr+WoCIIrk0lHZZPa8iz10mFrYuJfHNWIJe
+O55vTIyrb4qTi0trzM4REgTGA15gbwlSkKhSqdw+Ynk8CPTAULrEStDbeL1zDkAl
+7iJAvkA44p2OWpUKOQKeKfdDiegVAW4c0vRPnVkCq9nQgfQLsqA9fgGj13K8oSkO
+fuUBrk3S149GxKu2iZpIBJsQNrefJQFvzt37sO6cSYWx1lQBlbJ5ls879dReMSZ3
+EUqhbNeAWPEg4QqoW3HtK1M6UEZs2xo=
+=/D6c
+-----END PGP PUBLIC KEY BLOCK-----
+EOF
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+// checkDecryptsWithPrivateKey checks, that the message can be decrypted with rsa3072PrivateKey.
+func checkDecryptsWithPrivateKey(value string) error {
+	key, err := protonpgp.NewPrivateKeyFromArmored(rsa3072PrivateKey, []byte("correct horse battery staple"))
+	if err != nil {
+		return err
+	}
+	decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(key).New()
+	if err != nil {
+		return err
+	}
+	decrypted, err := decrypter.Decrypt([]byte(value), protonpgp.Armor)
+	if err != nil {
+		return err
+	}
+	if decrypted.String() != "This is example of GPG encrypted message." {
+		return fmt.Errorf("unexpected decrypted content %q", decrypted.String())
+	}
+	return nil
+}
+
+func TestProviderDefaultPublicKeys(t *testing.T) {
+	t.Parallel()
+
+	var id string
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(providerDefaultPublicKeysConfig, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.#", "0"),
+					checkResourceID("opengpg_encrypted_message.example", &id, true),
+				),
+			},
+			{
+				// Messages are encrypted again, once default public keys change.
+				Config: fmt.Sprintf(providerDefaultPublicKeysConfig, "default_public_keys = [var.opengpg_public_key_rsa]"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.#", "0"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.#", "1"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.0", "7b4273093fb8adb18ec2190bd8a1a867bacce331"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPrivateKey),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPassphrase("correct horse battery staple")),
+					checkResourceID("opengpg_encrypted_message.example", &id, false),
+				),
+			},
+			{
+				Config:             fmt.Sprintf(providerDefaultPublicKeysConfig, "default_public_keys = [var.opengpg_public_key_rsa]"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				// Profile only applies to new messages.
+				Config: fmt.Sprintf(providerDefaultPublicKeysConfig, "default_public_keys = [var.opengpg_public_key_rsa]\n  profile = \"rfc4880\""),
+				Check:  checkResourceID("opengpg_encrypted_message.example", &id, true),
+			},
+		},
+	})
+}
+
+func TestProviderDefaultPublicKeysOnly(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerDefaultPublicKeysOnlyConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.0", "7b4273093fb8adb18ec2190bd8a1a867bacce331"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPrivateKey),
+				),
+			},
+		},
+	})
+}
+
+func TestProviderEvaluationTime(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(providerDefaultPublicKeysConfig, "default_public_keys = [var.opengpg_public_key_ecc25519_expired]"),
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`invalid public keys: default public key #0 \(key ID 9edb3fd181a2ee9f, email foo@coop.no\) expired at 2024-09-25T09:23:42Z`)),
+			},
+			{
+				// The key was still valid at the evaluation time.
+				Config: fmt.Sprintf(providerDefaultPublicKeysConfig, "default_public_keys = [var.opengpg_public_key_ecc25519_expired]\n  evaluation_time = \"2024-09-25T00:00:00Z\""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.0", "d8a9fe58bea659391452ce3d9edb3fd181a2ee9f"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPassphrase("correct horse battery staple")),
+				),
+			},
+		},
+	})
+}
+
+func TestProviderBadArguments(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		arguments   string
+		expectError *regexp.Regexp
+	}{
+		{
+			name:        "malformed default public key",
+			arguments:   `default_public_keys = ["not a key"]`,
+			expectError: regexp.MustCompile(regexSpaceOrNewline(`getting default public keys: decoding public key #0`)),
+		},
+		{
+			name:        "unknown profile",
+			arguments:   `profile = "rfc2440"`,
+			expectError: regexp.MustCompile(regexSpaceOrNewline(`expected profile to be one of ."default" "rfc4880" "rfc9580"., got rfc2440`)),
+		},
+		{
+			name:        "malformed evaluation time",
+			arguments:   `evaluation_time = "2024-09-01"`,
+			expectError: regexp.MustCompile(regexSpaceOrNewline(`"evaluation_time" to be a valid RFC3339 date`)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config:      fmt.Sprintf(providerDefaultPublicKeysConfig, tc.arguments),
+						ExpectError: tc.expectError,
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestProviderRecipientPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		minKeyValidityAction string
+		resourceArguments    string
+		expectError          *regexp.Regexp
+	}{
+		{
+			name:                 "expires within",
+			minKeyValidityAction: "error",
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`invalid public keys: public key #0 \(key ID [0-9a-f]{16}, email example@coop.no\) expires at [0-9TZ:-]+, which is within min_key_validity of 2160h0m0s`)),
+		},
+		{name: "expires within, warning only", minKeyValidityAction: "warn"},
+		{name: "overridden by resource", minKeyValidityAction: "error", resourceArguments: `min_key_validity = "24h"`},
+		{
+			name:                 "action overridden by resource",
+			minKeyValidityAction: "warn",
+			resourceArguments:    "min_key_validity = \"2160h\"\n  min_key_validity_action = \"error\"",
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`which is within min_key_validity of 2160h0m0s`)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config:      fmt.Sprintf(providerRecipientPolicyConfig, tc.minKeyValidityAction, tc.resourceArguments),
+						ExpectError: tc.expectError,
+						Check:       resource.TestCheckResourceAttrSet("opengpg_encrypted_message.example", "result"),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestProviderRecipientAllowlist(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		defaultPublicKeys    string
+		allowedFingerprints  string
+		requiredFingerprints string
+		publicKeys           string
+		planOnly             bool
+		expectError          *regexp.Regexp
+	}{
+		{
+			name:                "allowed",
+			allowedFingerprints: `["7b4273093fb8adb18ec2190bd8a1a867bacce331"]`,
+			publicKeys:          `[var.opengpg_public_key_rsa]`,
+		},
+		{
+			name:                "not allowed",
+			allowedFingerprints: `["d8a9fe58bea659391452ce3d9edb3fd181a2ee9f"]`,
+			publicKeys:          `[var.opengpg_public_key_rsa]`,
+			planOnly:            true,
+			expectError:         regexp.MustCompile(regexSpaceOrNewline(`recipients not allowed by recipient_policy: public key #0 \(fingerprint 7b4273093fb8adb18ec2190bd8a1a867bacce331(, email [^)]+)?\) is not in allowed_recipient_fingerprints`)),
+		},
+		{
+			// Generated key is only known during apply.
+			name:                "not allowed, known after apply",
+			allowedFingerprints: `["7B4273093FB8ADB18EC2190BD8A1A867BACCE331"]`,
+			publicKeys:          `[var.opengpg_public_key_rsa, opengpg_private_key.example.public_key_armored]`,
+			expectError:         regexp.MustCompile(regexSpaceOrNewline(`public key #1 \(fingerprint [0-9a-f]{40}, email example@coop.no\) is not in allowed_recipient_fingerprints`)),
+		},
+		{
+			name:                "default public key not allowed",
+			defaultPublicKeys:   `[var.opengpg_public_key_rsa]`,
+			allowedFingerprints: `["d8a9fe58bea659391452ce3d9edb3fd181a2ee9f"]`,
+			planOnly:            true,
+			expectError:         regexp.MustCompile(regexSpaceOrNewline(`default public key #0 \(fingerprint 7b4273093fb8adb18ec2190bd8a1a867bacce331(, email [^)]+)?\) is not in allowed_recipient_fingerprints`)),
+		},
+		{
+			name:                 "required missing",
+			requiredFingerprints: `["7b4273093fb8adb18ec2190bd8a1a867bacce331"]`,
+			publicKeys:           `[opengpg_private_key.example.public_key_armored]`,
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`required recipient 7b4273093fb8adb18ec2190bd8a1a867bacce331 is missing from public keys`)),
+		},
+		{
+			name:                 "required from default public keys",
+			defaultPublicKeys:    `[var.opengpg_public_key_rsa]`,
+			requiredFingerprints: `["7b4273093fb8adb18ec2190bd8a1a867bacce331"]`,
+			publicKeys:           `[opengpg_private_key.example.public_key_armored]`,
+		},
+		{
+			name:                "malformed fingerprint",
+			allowedFingerprints: `["bacce331"]`,
+			publicKeys:          `[var.opengpg_public_key_rsa]`,
+			expectError:         regexp.MustCompile(regexSpaceOrNewline(`to be a fingerprint of 40 or 64 hexadecimal digits, got bacce331`)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			orNull := func(value string) string {
+				if value == "" {
+					return "null"
+				}
+				return value
+			}
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(providerRecipientAllowlistConfig,
+							orNull(tc.defaultPublicKeys), orNull(tc.allowedFingerprints), orNull(tc.requiredFingerprints), orNull(tc.publicKeys)),
+						PlanOnly:    tc.planOnly,
+						ExpectError: tc.expectError,
+						Check:       resource.TestCheckResourceAttrSet("opengpg_encrypted_message.example", "result"),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestProviderAlgorithmPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		defaultPublicKeys string
+		action            string
+		publicKeys        string
+		expectError       *regexp.Regexp
+	}{
+		{name: "allowed", action: "error", publicKeys: `[var.opengpg_public_key_rsa]`},
+		{
+			name:        "weak key",
+			action:      "error",
+			publicKeys:  `[var.opengpg_public_key_rsa, var.opengpg_public_key_rsa_2048]`,
+			expectError: regexp.MustCompile(regexSpaceOrNewline(`public keys not allowed by algorithm_policy: public key #1 \(key ID fb23ea234b84c501, email weak-rsa@coop.no\): primary key is RSA of 2048 bits, which is shorter than 3072 bits, encryption subkey c3a5555566be1618 is RSA of 2048 bits, which is shorter than 3072 bits`)),
+		},
+		{
+			name:              "weak default key",
+			defaultPublicKeys: `[var.opengpg_public_key_rsa_2048]`,
+			action:            "error",
+			publicKeys:        `[var.opengpg_public_key_rsa]`,
+			expectError:       regexp.MustCompile(regexSpaceOrNewline(`default public key #0 \(key ID fb23ea234b84c501, email weak-rsa@coop.no\): primary key is RSA of 2048 bits`)),
+		},
+		{name: "weak key, warning only", action: "warn", publicKeys: `[var.opengpg_public_key_rsa_2048]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			defaultPublicKeys := tc.defaultPublicKeys
+			if defaultPublicKeys == "" {
+				defaultPublicKeys = "null"
+			}
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config:      fmt.Sprintf(providerAlgorithmPolicyConfig, defaultPublicKeys, tc.action, tc.publicKeys),
+						PlanOnly:    tc.expectError != nil,
+						ExpectError: tc.expectError,
+						Check:       resource.TestCheckResourceAttrSet("opengpg_encrypted_message.example", "result"),
+					},
+				},
+			})
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package opengpg_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/coopnorge/terraform-provider-opengpg/opengpg"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -16,6 +18,19 @@ var providerFactories = map[string]func() (*schema.Provider, error){
 	},
 }
 
+// protoV5ProviderFactories are used instead of providerFactories, when testing
+// features of the terraform-plugin-framework provider, like ephemeral resources.
+var protoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	"opengpg": func() (tfprotov5.ProviderServer, error) {
+		serverFactory, err := opengpg.ProviderServerFactory(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		return serverFactory(), nil
+	},
+}
+
 func TestProvider(t *testing.T) {
 	t.Parallel()
 
@@ -25,3 +40,38 @@ func TestProvider(t *testing.T) {
 		t.Fatalf("validating provider internally: %v", err)
 	}
 }
+
+func TestProviderServer(t *testing.T) {
+	t.Parallel()
+
+	server, err := protoV5ProviderFactories["opengpg"]()
+	if err != nil {
+		t.Fatalf("creating provider server: %v", err)
+	}
+
+	// Mux server fails, if the schemas of the providers are not compatible.
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("getting provider schema: %v", err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		if diagnostic.Severity == tfprotov5.DiagnosticSeverityError {
+			t.Fatalf("getting provider schema: %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+	}
+
+	if _, ok := resp.EphemeralResourceSchemas["opengpg_decrypted_message"]; !ok {
+		t.Errorf("ephemeral resource %q is not served", "opengpg_decrypted_message")
+	}
+
+	for _, name := range []string{"key_id", "fingerprint", "primary_email", "is_expired"} {
+		if _, ok := resp.Functions[name]; !ok {
+			t.Errorf("function %q is not served", name)
+		}
+	}
+
+	if _, ok := resp.ResourceSchemas["opengpg_encrypted_message"]; !ok {
+		t.Errorf("resource %q is not served", "opengpg_encrypted_message")
+	}
+}
@@ -0,0 +1,42 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var cleartextSignedMessageConfig = `
+resource "opengpg_cleartext_signed_message" "example" {
+  content     = <<EOF
+allowed:
+- 10.0.0.0/8
+- 192.168.0.0/16
+EOF
+  private_key = var.opengpg_private_key_curve
+}
+` +
+	variableConfig("opengpg_private_key_curve", "A private-key of type ECC 25519, not protected by a passphrase", curvePrivateKey)
+
+func TestGPGCleartextSignedMessage(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: cleartextSignedMessageConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_cleartext_signed_message.example", "key_id", "75fac23672df26de"),
+					resource.TestMatchResourceAttr("opengpg_cleartext_signed_message.example", "result", regexp.MustCompile(`^-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA256\n\nallowed:\n- - 10.0.0.0/8\n- - 192.168.0.0/16\n`)),
+				),
+			},
+			{
+				Config:             cleartextSignedMessageConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
@@ -0,0 +1,164 @@
+package opengpg
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceGPGDetachedSignature() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGPGSigningCreate(signDetached),
+		Read:   resourceStateOnlyRead,
+		Update: resourceStateOnlyUpdate,
+		Delete: resourceStateOnlyDelete,
+
+		CustomizeDiff: customdiff.All(
+			customizeDiffSourceChecksum,
+			customizeDiffStateHash(
+				hashedAttribute{key: "content"},
+				hashedAttribute{key: "passphrase"},
+			),
+		),
+
+		Schema: signingSchema(map[string]*schema.Schema{
+			"content": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"content", "source"},
+			},
+			"source": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"content", "source"},
+			},
+			"source_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"result": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		}),
+	}
+}
+
+// customizeDiffSourceChecksum forces re-creation of the resource, when content
+// of the file in "source" property no longer matches "source_sha256" in state.
+func customizeDiffSourceChecksum(_ context.Context, diff *schema.ResourceDiff, _ any) error {
+	source, ok := diff.Get("source").(string)
+	if !ok || source == "" {
+		return nil
+	}
+
+	checksum, err := sha256File(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		// File might be created by other resource during apply, with content
+		// unknown until then, so existing resources are created again.
+		if err := diff.SetNewComputed("source_sha256"); err != nil {
+			return fmt.Errorf("setting %q property: %w", "source_sha256", err)
+		}
+
+		if diff.Id() == "" {
+			return nil
+		}
+
+		return diff.ForceNew("source_sha256")
+	}
+	if err != nil {
+		return fmt.Errorf("calculating checksum of %q: %w", source, err)
+	}
+
+	if diff.Get("source_sha256") == checksum {
+		return nil
+	}
+
+	if err := diff.SetNew("source_sha256", checksum); err != nil {
+		return fmt.Errorf("setting %q property: %w", "source_sha256", err)
+	}
+
+	if diff.Id() == "" {
+		return nil
+	}
+
+	return diff.ForceNew("source_sha256")
+}
+
+// signDetached creates detached signature of the file in "source", or of the
+// "content".
+func signDetached(data *schema.ResourceData, signer *encryption.Signer) (string, error) {
+	if source, ok := data.Get("source").(string); ok && source != "" {
+		signature, checksum, err := signFile(signer, source)
+		if err != nil {
+			return "", fmt.Errorf("signing file %q: %w", source, err)
+		}
+
+		if err := data.Set("source_sha256", checksum); err != nil {
+			return "", fmt.Errorf("setting %q property: %w", "source_sha256", err)
+		}
+
+		return signature, nil
+	}
+
+	// Only hash of the content is planned.
+	plaintextMessage, err := getConfigString(data, "content")
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := encryption.SignDetachedAndEncode(signer, strings.NewReader(plaintextMessage))
+	if err != nil {
+		return "", fmt.Errorf("signing message: %w", err)
+	}
+
+	return signature, nil
+}
+
+// signFile creates detached signature of the file, and calculates SHA-256
+// checksum of the signed content in the same pass.
+func signFile(signer *encryption.Signer, path string) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	signature, err := encryption.SignDetachedAndEncode(signer, io.TeeReader(file, hash))
+	if err != nil {
+		return "", "", err
+	}
+
+	return signature, fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// sha256File calculates SHA-256 checksum of the file content, without reading
+// the whole file into memory.
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
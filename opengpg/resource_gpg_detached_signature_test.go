@@ -0,0 +1,192 @@
+package opengpg_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var detachedSignatureContentConfig = `
+resource "opengpg_detached_signature" "example" {
+  content     = "This is example of GPG signed content."
+  private_key = var.opengpg_private_key_curve
+}
+` +
+	variableConfig("opengpg_private_key_curve", "A private-key of type ECC 25519, not protected by a passphrase", curvePrivateKey)
+
+// detachedSignatureSourceConfig must be formatted with path of the file to sign.
+var detachedSignatureSourceConfig = `
+resource "opengpg_detached_signature" "example" {
+  source      = %q
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "correct horse battery staple"
+}
+` +
+	variableConfig("opengpg_private_key_rsa", "A private-key of type RSA 3072, protected by a passphrase", rsa3072PrivateKey)
+
+// detachedSignatureSourceCreatedDuringApplyConfig must be formatted with content
+// and path of the file to sign, which is written by other resource during apply.
+var detachedSignatureSourceCreatedDuringApplyConfig = `
+resource "opengpg_encrypted_message" "input" {
+  content     = %q
+  passphrase  = "correct horse battery staple"
+  output_path = %q
+  omit_result = true
+}
+
+resource "opengpg_detached_signature" "example" {
+  source      = opengpg_encrypted_message.input.output_path
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "correct horse battery staple"
+
+  depends_on = [opengpg_encrypted_message.input]
+}
+` +
+	variableConfig("opengpg_private_key_rsa", "A private-key of type RSA 3072, protected by a passphrase", rsa3072PrivateKey)
+
+const detachedSignatureBothConfig = `
+resource "opengpg_detached_signature" "example" {
+  content     = "This is example of GPG signed content."
+  source      = "SHA256SUMS"
+  private_key = "not used"
+}
+`
+
+func TestGPGDetachedSignatureContent(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: detachedSignatureContentConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_detached_signature.example", "key_id", "75fac23672df26de"),
+					resource.TestMatchResourceAttr("opengpg_detached_signature.example", "result", regexp.MustCompile(`^-----BEGIN PGP SIGNATURE-----`)),
+				),
+			},
+			{
+				Config:             detachedSignatureContentConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
+
+func TestGPGDetachedSignatureBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      detachedSignatureBothConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`"content": only one of .content,source. can be specified`)),
+			},
+		},
+	})
+}
+
+func TestGPGDetachedSignatureSource(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "SHA256SUMS")
+	if err := os.WriteFile(source, []byte("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  empty.txt\n"), 0o600); err != nil {
+		t.Fatalf("writing file to sign: %v", err)
+	}
+
+	config := fmt.Sprintf(detachedSignatureSourceConfig, source)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_detached_signature.example", "key_id", "d8a1a867bacce331"),
+					resource.TestCheckResourceAttr("opengpg_detached_signature.example", "source_sha256", "292ed7cfb339d7cc3e30aceca5422844d17b2bceaf2da22eb76c4e319155fed9"),
+				),
+			},
+			{
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				PreConfig: func() {
+					if err := os.WriteFile(source, []byte("changed\n"), 0o600); err != nil {
+						t.Fatalf("changing signed file: %v", err)
+					}
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestGPGDetachedSignatureSourceCreatedDuringApply(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "message.asc")
+
+	checkSignsSource := resource.TestCheckResourceAttrWith("opengpg_detached_signature.example", "result", func(value string) error {
+		key, err := protonpgp.NewKeyFromArmored(rsa3072PrivateKey)
+		if err != nil {
+			return err
+		}
+		publicKey, err := key.GetArmoredPublicKey()
+		if err != nil {
+			return err
+		}
+		signer, err := encryption.GetRecipient(publicKey)
+		if err != nil {
+			return err
+		}
+		content, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		verification, err := encryption.VerifyDetached([]*encryption.Recipient{signer}, content, value)
+		if err != nil {
+			return err
+		}
+		return verification.Err
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				// The file does not exist during plan.
+				Config: fmt.Sprintf(detachedSignatureSourceCreatedDuringApplyConfig, "first", source),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opengpg_detached_signature.example", "source_sha256", "opengpg_encrypted_message.input", "output_sha256"),
+					checkSignsSource,
+				),
+			},
+			{
+				// The file is removed, and written again with other content during apply.
+				PreConfig: func() {
+					if err := os.Remove(source); err != nil {
+						t.Fatalf("removing source file: %v", err)
+					}
+				},
+				Config: fmt.Sprintf(detachedSignatureSourceCreatedDuringApplyConfig, "second", source),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opengpg_detached_signature.example", "source_sha256", "opengpg_encrypted_message.input", "output_sha256"),
+					checkSignsSource,
+				),
+			},
+		},
+	})
+}
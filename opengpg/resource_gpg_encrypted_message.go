@@ -1,34 +1,113 @@
 package opengpg
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 
 	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-func resourceGPGEncryptedMessage() *schema.Resource {
+// resourceGPGEncryptedMessage returns the resource. Validation of its config
+// reads the provider configuration with providerMeta.
+func resourceGPGEncryptedMessage(providerMeta func() any) *schema.Resource {
 	return &schema.Resource{
 		// TODO: Migrate to <Create/Read/Delete/Update>Context
 		Create: resourceGPGEncryptedMessageCreate,
-		// Those 2 functions below does nothing, but must be implemented.
+		// Those 2 functions below only manage the file in "output_path", if set.
 		Read:   resourceGPGEncryptedMessageRead,
 		Delete: resourceGPGEncryptedMessageDelete,
+		// Update only migrates key IDs in state to fingerprints and plain SHA-256 checksums to HMAC,
+		// and changes checks of key validity, everything else forces re-creation.
+		Update: resourceGPGEncryptedMessageUpdate,
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceGPGEncryptedMessageV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceGPGEncryptedMessageStateUpgradeV0,
+			},
+		},
+
+		CustomizeDiff: customdiff.All(
+			customizeDiffRecipientsRequired,
+			customizeDiffSourceChecksum,
+			customizeDiffRecipientFingerprints,
+			customizeDiffRecipientAllowlist,
+			customizeDiffRecipientAlgorithms,
+			customizeDiffRecipientValidity,
+			customizeDiffInvalidRecipients,
+			customizeDiffStateHash(
+				hashedAttribute{key: "content"},
+				hashedAttribute{key: "content_base64", decode: decodeBase64},
+				hashedAttribute{key: "passphrase"},
+				hashedAttribute{key: "signing_key_passphrase"},
+			),
+		),
+		ValidateRawResourceConfigFuncs: []schema.ValidateRawResourceConfigFunc{
+			validateRecipientValidity(providerMeta),
+			validateRecipientAlgorithms(providerMeta),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"content": {
-				Type:      schema.TypeString,
-				Required:  true,
-				ForceNew:  true,
-				Sensitive: true,
-				StateFunc: sha256sum,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"content", "content_wo", "content_base64", "source"},
+			},
+			"content_wo": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				WriteOnly:    true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"content", "content_wo", "content_base64", "source"},
+			},
+			"content_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsBase64,
+				ExactlyOneOf: []string{"content", "content_wo", "content_base64", "source"},
+			},
+			"source": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"content", "content_wo", "content_base64", "source"},
+			},
+			"source_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// Write-only content is never in the plan, so changing it can only be detected by its version.
+			"content_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"content_wo"},
 			},
 			"public_keys": {
 				Type:     schema.TypeList,
 				MinItems: 1,
 				ForceNew: true,
-				Required: true,
+				Optional: true,
 				Elem: &schema.Schema{
 					Type:     schema.TypeString,
 					ForceNew: true,
@@ -39,23 +118,115 @@ func resourceGPGEncryptedMessage() *schema.Resource {
 						}
 						recipient, err := encryption.GetRecipient(publicKey)
 						if err != nil {
-							// We only keep KeyId in state, as we want to keep it small and also
+							// We only keep fingerprint in state, as we want to keep it small and also
 							// we always read public keys anyway. If public key is malformed,
 							// creation of resource will fail anyway, so it's fine to set it here.
 							return "MALFORMED KEY"
 						}
 
-						// Instead of full ASCII-armored key, write only KeyId to state.
-						return recipient.GetKeyID()
+						// Instead of full ASCII-armored key, write only fingerprint to state.
+						return recipient.GetFingerprint()
 					},
+					DiffSuppressFunc: suppressLegacyKeyIDDiff,
 				},
 			},
+			// Key validity is only checked before encryption, so changing it does not re-create the resource.
+			"min_key_validity": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+			},
+			"min_key_validity_action": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(keyValidityActions(), false),
+				RequiredWith: []string{"min_key_validity"},
+			},
+			"invalid_key_action": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(invalidKeyActions(), false),
+			},
+			"recipient_fingerprints": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"passphrase": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"s2k_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(encryption.S2KModes(), false),
+			},
+			"output_format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(encryption.OutputFormats(), false),
+			},
+			// Overrides "profile" of the provider.
+			"profile": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(encryption.Profiles(), false),
+			},
+			"output_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"file_permission": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^0?[0-7]{3}$`), "must be a file permission in octal notation, e.g. 0600"),
+			},
+			"output_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// Omitting the result keeps the ciphertext only in the output file, not in the state.
+			"omit_result": {
+				Type:         schema.TypeBool,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"output_path"},
+			},
+			"signing_key": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				ForceNew:  true,
+				Sensitive: true,
+				// Like private_key of the signing resources, only its checksum is kept in state.
+				StateFunc: sha256sum,
+			},
+			"signing_key_passphrase": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"signing_key"},
+			},
 			"result": {
 				Type:      schema.TypeString,
 				Computed:  true,
 				ForceNew:  true,
 				Sensitive: true,
 			},
+			"result_base64": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
 		},
 	}
 }
@@ -79,56 +250,399 @@ func getRecipients(data *schema.ResourceData) ([]*encryption.Recipient, error) {
 	return encryption.GetRecipients(publicKeys)
 }
 
-func savePublicKeys(data *schema.ResourceData, recipients []*encryption.Recipient) error {
-	// Store ID of each public key, to store them in state (StateFunc does not work for TypeList for some reason).
-	pksIDs := []string{}
-
-	for _, recipient := range recipients {
-		pksIDs = append(pksIDs, recipient.GetKeyID())
+// savePublicKeys stores fingerprints of the configured public keys, and of all
+// recipients including the default public keys of the provider.
+func savePublicKeys(data *schema.ResourceData, recipients []*encryption.Recipient, allRecipients []*encryption.Recipient) error {
+	// Store fingerprint of each public key, to store them in state (StateFunc does not work for TypeList for some reason).
+	if err := data.Set("public_keys", getFingerprints(recipients)); err != nil {
+		return fmt.Errorf("setting %q property: %w", "public_keys", err)
 	}
 
-	if err := data.Set("public_keys", pksIDs); err != nil {
-		return fmt.Errorf("setting %q property: %w", "public_keys", err)
+	if err := data.Set("recipient_fingerprints", getFingerprints(allRecipients)); err != nil {
+		return fmt.Errorf("setting %q property: %w", "recipient_fingerprints", err)
 	}
 
 	return nil
 }
 
-func resourceGPGEncryptedMessageCreate(data *schema.ResourceData, _ any) error {
-	recipients, err := getRecipients(data)
+// getContent returns the content to encrypt, either from "content", from
+// base64-encoded "content_base64", or from write-only "content_wo". All of them
+// are read from the config, as only a hash of the content is planned.
+func getContent(data *schema.ResourceData) ([]byte, error) {
+	// Empty content is encrypted as well, so properties are checked for being set, not for being empty.
+	content, ok, err := lookupConfigString(data, "content")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return []byte(content), nil
+	}
+
+	contentBase64, ok, err := lookupConfigString(data, "content_base64")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		decoded, err := base64.StdEncoding.DecodeString(contentBase64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding property %q: %w", "content_base64", err)
+		}
+
+		return decoded, nil
+	}
+
+	contentWO, ok, err := lookupConfigString(data, "content_wo")
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("data in property %q was not a string", "content_wo")
+	}
+
+	return []byte(contentWO), nil
+}
+
+func getEncryptionOptions(data *schema.ResourceData) (encryption.EncryptionOptions, error) {
+	options := encryption.EncryptionOptions{}
+
+	if _, ok := data.GetOk("signing_key"); ok {
+		signer, err := getSigner(data, "signing_key", "signing_key_passphrase")
+		if err != nil {
+			return options, fmt.Errorf("getting signer: %w", err)
+		}
+
+		options.Signer = signer
+	}
+
+	passphrase, err := getConfigString(data, "passphrase")
+	if err != nil {
+		return options, err
+	}
+
+	options.Passphrase = passphrase
+
+	s2kMode, ok := data.Get("s2k_mode").(string)
+	if !ok {
+		return options, fmt.Errorf("data in property %q was not a string", "s2k_mode")
+	}
+
+	options.S2KMode = s2kMode
+
+	outputFormat, ok := data.Get("output_format").(string)
+	if !ok {
+		return options, fmt.Errorf("data in property %q was not a string", "output_format")
+	}
+
+	options.OutputFormat = outputFormat
+
+	profile, ok := data.Get("profile").(string)
+	if !ok {
+		return options, fmt.Errorf("data in property %q was not a string", "profile")
+	}
+
+	options.Profile = profile
+
+	return options, nil
+}
+
+func resourceGPGEncryptedMessageCreate(data *schema.ResourceData, meta any) error {
+	config := getProviderMeta(meta)
+
+	configuredRecipients, err := getRecipients(data)
 	if err != nil {
 		return fmt.Errorf("getting recipients: %w", err)
 	}
 
-	if err := savePublicKeys(data, recipients); err != nil {
+	// Keys, which were unknown during plan, are only checked here.
+	if err := checkRecipientAllowlist(configuredRecipients, config); err != nil {
+		return err
+	}
+
+	if err := checkRecipientsAlgorithmsError(configuredRecipients, config); err != nil {
+		return err
+	}
+
+	recipients := config.withDefaultRecipients(configuredRecipients)
+
+	if err := savePublicKeys(data, configuredRecipients, recipients); err != nil {
 		return fmt.Errorf("saving public keys: %w", err)
 	}
 
-	plaintextMessage, ok := data.Get("content").(string)
+	options, err := getEncryptionOptions(data)
+	if err != nil {
+		return fmt.Errorf("getting encryption options: %w", err)
+	}
+
+	if options.Profile == "" {
+		options.Profile = config.profile
+	}
+
+	options.Time = config.evaluationTime
+
+	var plaintextMessage io.Reader
+
+	var sourceHash hash.Hash
+
+	if source, ok := data.Get("source").(string); ok && source != "" {
+		file, err := os.Open(source)
+		if err != nil {
+			return fmt.Errorf("opening file %q: %w", source, err)
+		}
+		defer file.Close()
+
+		// SHA-256 checksum of the file is calculated in the same pass as encryption.
+		sourceHash = sha256.New()
+		plaintextMessage = io.TeeReader(file, sourceHash)
+	} else {
+		content, err := getContent(data)
+		if err != nil {
+			return fmt.Errorf("getting content: %w", err)
+		}
+
+		plaintextMessage = bytes.NewReader(content)
+	}
+
+	omitResult, ok := data.Get("omit_result").(bool)
 	if !ok {
-		return fmt.Errorf("data in property %q was not a string", "content")
+		return fmt.Errorf("data in property %q was not a bool", "omit_result")
 	}
 
-	encryptedMessage, err := encryption.EncryptAndEncodeMessage(recipients, plaintextMessage)
-	if err != nil {
-		return fmt.Errorf("encrypting message: %w", err)
+	var encryptedMessage string
+
+	// ID is SHA-256 checksum of the encrypted message, also when it is not kept in memory.
+	var id string
+
+	if outputPath, ok := data.Get("output_path").(string); ok && outputPath != "" {
+		var result *bytes.Buffer
+		if !omitResult {
+			result = bytes.NewBuffer(nil)
+		}
+
+		checksum, err := writeOutputFile(data, outputPath, options.OutputFormat, func(dst io.Writer) error {
+			messageHash := sha256.New()
+
+			var output io.Writer = messageHash
+			if result != nil {
+				output = io.MultiWriter(messageHash, result)
+			}
+
+			if _, err := encryption.Encrypt(context.Background(), io.MultiWriter(output, dst), plaintextMessage, recipients, options); err != nil {
+				return fmt.Errorf("encrypting message: %w", err)
+			}
+
+			id = fmt.Sprintf("%x", messageHash.Sum(nil))
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("writing encrypted message to %q: %w", outputPath, err)
+		}
+
+		if err := data.Set("output_sha256", checksum); err != nil {
+			return fmt.Errorf("setting %q property: %w", "output_sha256", err)
+		}
+
+		if result != nil {
+			encryptedMessage = result.String()
+		}
+	} else {
+		encryptedMessage, err = encryption.EncryptAndEncodeMessageWithOptions(recipients, plaintextMessage, options)
+		if err != nil {
+			return fmt.Errorf("encrypting message: %w", err)
+		}
+
+		id = sha256sum(encryptedMessage)
 	}
 
-	if err := data.Set("result", encryptedMessage); err != nil {
+	if sourceHash != nil {
+		if err := data.Set("source_sha256", fmt.Sprintf("%x", sourceHash.Sum(nil))); err != nil {
+			return fmt.Errorf("setting %q property: %w", "source_sha256", err)
+		}
+	}
+
+	// Only the attribute of the requested output format holds the message.
+	result, resultBase64 := encryptedMessage, ""
+	if options.OutputFormat == encryption.OutputFormatBinaryBase64 {
+		result, resultBase64 = "", encryptedMessage
+	}
+
+	if omitResult {
+		result, resultBase64 = "", ""
+	}
+
+	if err := data.Set("result", result); err != nil {
 		return fmt.Errorf("setting %q property: %w", "result", err)
 	}
 
-	// Calculate SHA-256 checksum of message for ID.
-	data.SetId(sha256sum(encryptedMessage))
+	if err := data.Set("result_base64", resultBase64); err != nil {
+		return fmt.Errorf("setting %q property: %w", "result_base64", err)
+	}
+
+	data.SetId(id)
 
 	return nil
 }
 
-func resourceGPGEncryptedMessageRead(_ *schema.ResourceData, _ any) error {
+// customizeDiffRecipientsRequired fails the plan, when the message would have
+// neither a passphrase, nor any public key. Public keys may also come from
+// "default_public_keys" of the provider, so it can not be validated by schema.
+func customizeDiffRecipientsRequired(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+	if len(getProviderMeta(meta).defaultRecipients) > 0 {
+		return nil
+	}
+
+	rawConfig := diff.GetRawConfig()
+	if rawConfig.IsNull() || !rawConfig.IsKnown() {
+		return nil
+	}
+
+	if !rawConfig.GetAttr("public_keys").IsNull() || !rawConfig.GetAttr("passphrase").IsNull() {
+		return nil
+	}
+
+	return fmt.Errorf("one of `passphrase,public_keys` must be specified, unless default_public_keys are configured for the provider")
+}
+
+// writeOutputFile streams the encrypted message written by encrypt to a
+// temporary file in the directory of path, decoding it on the fly in case of
+// binary output format, and renames it to path, so the file is either
+// replaced completely, or not at all. It returns SHA-256 checksum of the file.
+func writeOutputFile(data *schema.ResourceData, path string, outputFormat string, encrypt func(dst io.Writer) error) (string, error) {
+	filePermission, ok := data.Get("file_permission").(string)
+	if !ok {
+		return "", fmt.Errorf("data in property %q was not a string", "file_permission")
+	}
+
+	if filePermission == "" {
+		filePermission = "0600"
+	}
+
+	mode, err := strconv.ParseUint(filePermission, 8, 32)
+	if err != nil {
+		return "", fmt.Errorf("parsing property %q: %w", "file_permission", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("creating directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	// The temporary file is left behind only when it was renamed.
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	fileHash := sha256.New()
+
+	var output io.Writer = io.MultiWriter(file, fileHash)
+
+	var decoder *base64DecodingWriter
+	if outputFormat == encryption.OutputFormatBinaryBase64 {
+		decoder = &base64DecodingWriter{writer: output}
+		output = decoder
+	}
+
+	if err := encrypt(output); err != nil {
+		return "", err
+	}
+
+	if decoder != nil {
+		if err := decoder.Close(); err != nil {
+			return "", fmt.Errorf("decoding binary message: %w", err)
+		}
+	}
+
+	// CreateTemp always creates files with permission 0600.
+	if err := file.Chmod(os.FileMode(mode)); err != nil {
+		return "", fmt.Errorf("changing file permission: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("writing file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", fmt.Errorf("renaming temporary file: %w", err)
+	}
+
+	return fmt.Sprintf("%x", fileHash.Sum(nil)), nil
+}
+
+// base64DecodingWriter decodes the base64-encoded data written to it, and
+// writes the decoded bytes to the underlying writer. Incomplete 4-byte
+// quanta are held back until the next write.
+type base64DecodingWriter struct {
+	writer  io.Writer
+	pending []byte
+}
+
+func (w *base64DecodingWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+
+	n := len(w.pending) / 4 * 4
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(n))
+
+	m, err := base64.StdEncoding.Decode(decoded, w.pending[:n])
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := w.writer.Write(decoded[:m]); err != nil {
+		return 0, err
+	}
+
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+
+	return len(p), nil
+}
+
+// Close fails, when the data written to it was not complete base64.
+func (w *base64DecodingWriter) Close() error {
+	if len(w.pending) > 0 {
+		return fmt.Errorf("%d trailing bytes", len(w.pending))
+	}
+
+	return nil
+}
+
+// resourceGPGEncryptedMessageRead plans re-creation of the resource, when the
+// file in "output_path" property is missing, or was modified.
+func resourceGPGEncryptedMessageRead(data *schema.ResourceData, _ any) error {
+	outputPath, ok := data.Get("output_path").(string)
+	if !ok || outputPath == "" {
+		return nil
+	}
+
+	checksum, err := sha256File(outputPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		data.SetId("")
+
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("calculating checksum of %q: %w", outputPath, err)
+	}
+
+	if data.Get("output_sha256") != checksum {
+		data.SetId("")
+	}
+
 	return nil
 }
 
 func resourceGPGEncryptedMessageDelete(d *schema.ResourceData, _ any) error {
+	if outputPath, ok := d.Get("output_path").(string); ok && outputPath != "" {
+		if err := os.Remove(outputPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing %q: %w", outputPath, err)
+		}
+	}
+
 	d.SetId("")
 
 	return nil
@@ -0,0 +1,169 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// getAlgorithmPolicy returns "algorithm_policy" of the provider, and whether
+// it only warns.
+func getAlgorithmPolicy(data *schema.ResourceData) (encryption.AlgorithmPolicy, bool, error) {
+	policy := encryption.AlgorithmPolicy{}
+
+	minRSABits, ok := data.Get("algorithm_policy.0.min_rsa_bits").(int)
+	if !ok {
+		return policy, false, fmt.Errorf("data in property %q was not an int", "min_rsa_bits")
+	}
+
+	policy.MinRSABits = minRSABits
+
+	forbiddenAlgorithms, err := getStringList(data, "algorithm_policy.0.forbidden_algorithms")
+	if err != nil {
+		return policy, false, err
+	}
+
+	policy.ForbiddenAlgorithms = forbiddenAlgorithms
+
+	forbidSHA1, ok := data.Get("algorithm_policy.0.forbid_sha1").(bool)
+	if !ok {
+		return policy, false, fmt.Errorf("data in property %q was not a bool", "forbid_sha1")
+	}
+
+	policy.ForbidSHA1 = forbidSHA1
+
+	return policy, data.Get("algorithm_policy.0.action") == keyValidityActionWarn, nil
+}
+
+// checkAllRecipientsAlgorithms returns violations of "algorithm_policy" of the
+// provider by the configured public keys, and by the default public keys,
+// which are not configured already.
+func checkAllRecipientsAlgorithms(recipients []*encryption.Recipient, meta *providerMeta) ([]recipientValidityProblem, error) {
+	problems, err := checkRecipientsAlgorithms("public key", recipients, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultProblems, err := checkRecipientsAlgorithms("default public key", meta.defaultRecipients, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := map[string]bool{}
+	for _, recipient := range recipients {
+		configured[recipient.GetFingerprint()] = true
+	}
+
+	for _, problem := range defaultProblems {
+		if !configured[problem.fingerprint] {
+			problem.isDefault = true
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems, nil
+}
+
+func checkRecipientsAlgorithms(name string, recipients []*encryption.Recipient, meta *providerMeta) ([]recipientValidityProblem, error) {
+	problems := []recipientValidityProblem{}
+
+	for i, recipient := range recipients {
+		violations, err := meta.algorithmPolicy.Check(recipient, meta.now())
+		if err != nil {
+			return nil, fmt.Errorf("checking algorithms of %s #%d: %w", name, i, err)
+		}
+
+		if len(violations) == 0 {
+			continue
+		}
+
+		info, err := recipient.GetKeyInfo(meta.now())
+		if err != nil {
+			return nil, fmt.Errorf("describing %s #%d: %w", name, i, err)
+		}
+
+		problems = append(problems, recipientValidityProblem{
+			index:       i,
+			invalid:     true,
+			fingerprint: info.Fingerprint,
+			message:     fmt.Sprintf("%s #%d (%s): %s", name, i, describeKey(info), strings.Join(violations, ", ")),
+		})
+	}
+
+	return problems, nil
+}
+
+// checkRecipientsAlgorithmsError returns an error, when any of the recipients
+// violates "algorithm_policy" of the provider, unless it should only warn.
+func checkRecipientsAlgorithmsError(recipients []*encryption.Recipient, meta *providerMeta) error {
+	if meta.warnWeakAlgorithms {
+		return nil
+	}
+
+	problems, err := checkAllRecipientsAlgorithms(recipients, meta)
+	if err != nil {
+		return err
+	}
+
+	messages := []string{}
+	for _, problem := range problems {
+		messages = append(messages, problem.message)
+	}
+
+	if len(messages) > 0 {
+		return fmt.Errorf("public keys not allowed by algorithm_policy: %s", strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// customizeDiffRecipientAlgorithms fails the plan, when the message is about
+// to be encrypted with a public key, which violates "algorithm_policy" of the
+// provider. Existing messages are not encrypted again, so they are not checked.
+func customizeDiffRecipientAlgorithms(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+	if diff.Id() != "" {
+		return nil
+	}
+
+	recipients, known, err := getConfiguredRecipients(diff.GetRawConfig())
+	if err != nil || !known {
+		// Malformed keys are reported by Create, and unknown keys are checked by Create once they are known.
+		return nil
+	}
+
+	return checkRecipientsAlgorithmsError(recipients, getProviderMeta(meta))
+}
+
+// validateRecipientAlgorithms warns about public keys violating
+// "algorithm_policy" of the provider, when its action is "warn".
+func validateRecipientAlgorithms(providerMetaFunc func() any) schema.ValidateRawResourceConfigFunc {
+	return func(_ context.Context, req schema.ValidateResourceConfigFuncRequest, resp *schema.ValidateResourceConfigFuncResponse) {
+		config := getProviderMeta(providerMetaFunc())
+		if !config.warnWeakAlgorithms {
+			return
+		}
+
+		recipients, known, err := getConfiguredRecipients(req.RawConfig)
+		if err != nil || !known {
+			return
+		}
+
+		problems, err := checkAllRecipientsAlgorithms(recipients, config)
+		if err != nil {
+			return
+		}
+
+		for _, problem := range problems {
+			resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       "Public key uses weak algorithms",
+				Detail:        problem.message,
+				AttributePath: problem.attributePath(),
+			})
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// fingerprintPattern matches fingerprints of v4 and v6 keys.
+var fingerprintPattern = regexp.MustCompile(`^([0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+// validateFingerprint validates, that the value is a hex-encoded fingerprint of v4 or v6 key.
+func validateFingerprint(val any, key string) ([]string, []error) {
+	value, ok := val.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %q to be string", key)}
+	}
+
+	if !fingerprintPattern.MatchString(value) {
+		return nil, []error{fmt.Errorf("expected %q to be a fingerprint of 40 or 64 hexadecimal digits, got %s", key, value)}
+	}
+
+	return nil, nil
+}
+
+// checkRecipientAllowlist returns an error, when any of the recipients, or of
+// the default recipients of the provider, is not in
+// "allowed_recipient_fingerprints", or when any key in
+// "required_recipient_fingerprints" is missing from them.
+func checkRecipientAllowlist(recipients []*encryption.Recipient, meta *providerMeta) error {
+	policy := meta.recipientPolicy
+	messages := []string{}
+
+	configured := map[string]bool{}
+	for _, recipient := range recipients {
+		configured[strings.ToLower(recipient.GetFingerprint())] = true
+	}
+
+	if len(policy.allowedFingerprints) > 0 {
+		messages = append(messages, disallowedRecipients("public key", recipients, policy.allowedFingerprints, nil, meta.now())...)
+		messages = append(messages, disallowedRecipients("default public key", meta.defaultRecipients, policy.allowedFingerprints, configured, meta.now())...)
+	}
+
+	all := map[string]bool{}
+	for _, recipient := range meta.withDefaultRecipients(recipients) {
+		all[strings.ToLower(recipient.GetFingerprint())] = true
+	}
+
+	for _, fingerprint := range policy.requiredFingerprints {
+		if !all[fingerprint] {
+			messages = append(messages, fmt.Sprintf("required recipient %s is missing from public keys", fingerprint))
+		}
+	}
+
+	if len(messages) > 0 {
+		return fmt.Errorf("recipients not allowed by recipient_policy: %s", strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// disallowedRecipients describes the recipients, which are not allowed.
+// Recipients with skipped fingerprints are not checked.
+func disallowedRecipients(name string, recipients []*encryption.Recipient, allowed []string, skipped map[string]bool, t time.Time) []string {
+	messages := []string{}
+
+	for i, recipient := range recipients {
+		fingerprint := strings.ToLower(recipient.GetFingerprint())
+		if skipped[fingerprint] || slices.Contains(allowed, fingerprint) {
+			continue
+		}
+
+		description := fmt.Sprintf("fingerprint %s", fingerprint)
+		if email, ok := recipient.GetUserEmail(t); ok {
+			description = fmt.Sprintf("fingerprint %s, email %s", fingerprint, email)
+		}
+
+		messages = append(messages, fmt.Sprintf("%s #%d (%s) is not in allowed_recipient_fingerprints", name, i, description))
+	}
+
+	return messages
+}
+
+// customizeDiffRecipientAllowlist fails the plan, when the configured public
+// keys are not allowed by "recipient_policy" of the provider. Existing
+// messages are checked as well, so that the policy also applies to messages
+// created before it.
+func customizeDiffRecipientAllowlist(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+	recipients, known, err := getConfiguredRecipients(diff.GetRawConfig())
+	if err != nil || !known {
+		// Malformed keys are reported by Create, and unknown keys are checked by Create once they are known.
+		return nil
+	}
+
+	return checkRecipientAllowlist(recipients, getProviderMeta(meta))
+}
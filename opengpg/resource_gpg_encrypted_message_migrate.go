@@ -0,0 +1,190 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceGPGEncryptedMessageV0 is the schema of opengpg_encrypted_message
+// before version 1, which only kept 64-bit key IDs of public keys in state.
+func resourceGPGEncryptedMessageV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"content":            {Type: schema.TypeString, Optional: true},
+			"content_wo":         {Type: schema.TypeString, Optional: true, WriteOnly: true},
+			"content_wo_version": {Type: schema.TypeInt, Optional: true},
+			"content_base64":     {Type: schema.TypeString, Optional: true},
+			"source":             {Type: schema.TypeString, Optional: true},
+			"source_sha256":      {Type: schema.TypeString, Computed: true},
+			"public_keys": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"passphrase":             {Type: schema.TypeString, Optional: true},
+			"s2k_mode":               {Type: schema.TypeString, Optional: true},
+			"output_format":          {Type: schema.TypeString, Optional: true},
+			"output_path":            {Type: schema.TypeString, Optional: true},
+			"file_permission":        {Type: schema.TypeString, Optional: true},
+			"output_sha256":          {Type: schema.TypeString, Computed: true},
+			"omit_result":            {Type: schema.TypeBool, Optional: true},
+			"signing_key":            {Type: schema.TypeString, Optional: true},
+			"signing_key_passphrase": {Type: schema.TypeString, Optional: true},
+			"result":                 {Type: schema.TypeString, Computed: true},
+			"result_base64":          {Type: schema.TypeString, Computed: true},
+		},
+	}
+}
+
+// resourceGPGEncryptedMessageStateUpgradeV0 upgrades the state to version 1.
+// State does not contain the public keys, so their key IDs can not be turned
+// into fingerprints here. They are kept as they are, and replaced with
+// fingerprints of the configured keys by an in-place update, which is planned
+// by customizeDiffRecipientFingerprints.
+func resourceGPGEncryptedMessageStateUpgradeV0(_ context.Context, rawState map[string]any, _ any) (map[string]any, error) {
+	if rawState == nil {
+		return nil, fmt.Errorf("upgrading state from version 0: no state")
+	}
+
+	// Fingerprints are only known, once the configured keys are read.
+	delete(rawState, "recipient_fingerprints")
+
+	return rawState, nil
+}
+
+// suppressLegacyKeyIDDiff suppresses the diff of a public key, when the state
+// still has its 64-bit key ID from schema version 0, so that upgrading the
+// provider does not re-encrypt the message.
+func suppressLegacyKeyIDDiff(_, oldValue, newValue string, _ *schema.ResourceData) bool {
+	return isLegacyKeyIDOf(oldValue, newValue)
+}
+
+// isLegacyKeyIDOf returns whether the key ID belongs to the fingerprint. Key ID
+// is the last 64 bits of v4 fingerprint, and the first 64 bits of v6 fingerprint.
+func isLegacyKeyIDOf(keyID, fingerprint string) bool {
+	if len(keyID) != 16 {
+		return false
+	}
+
+	switch len(fingerprint) {
+	case 40:
+		return strings.HasSuffix(fingerprint, keyID)
+	case 64:
+		return strings.HasPrefix(fingerprint, keyID)
+	default:
+		return false
+	}
+}
+
+// customizeDiffRecipientFingerprints plans "recipient_fingerprints" from the
+// configured public keys, and the default public keys of the provider. Change
+// of the default keys forces re-creation of the message. In states migrated
+// from schema version 0, this plans an in-place update, which replaces key IDs
+// with fingerprints.
+func customizeDiffRecipientFingerprints(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+	recipients, known, err := getConfiguredRecipients(diff.GetRawConfig())
+	if err != nil {
+		// Malformed keys are reported by Create.
+		return nil
+	}
+
+	if !known {
+		return diff.SetNewComputed("recipient_fingerprints")
+	}
+
+	fingerprints := getFingerprints(getProviderMeta(meta).withDefaultRecipients(recipients))
+
+	oldFingerprints, ok := diff.Get("recipient_fingerprints").([]any)
+	if !ok {
+		return fmt.Errorf("expected type %T on key %q, got %T", []any{}, "recipient_fingerprints", diff.Get("recipient_fingerprints"))
+	}
+
+	if equalValues(oldFingerprints, fingerprints) {
+		return nil
+	}
+
+	if err := diff.SetNew("recipient_fingerprints", fingerprints); err != nil {
+		return fmt.Errorf("setting %q property: %w", "recipient_fingerprints", err)
+	}
+
+	// States migrated from schema version 0 have no fingerprints yet, and the
+	// message was only encrypted with the configured keys.
+	if len(oldFingerprints) == 0 && equalValues(getFingerprints(recipients), fingerprints) {
+		return nil
+	}
+
+	return forceNewIfExists(diff, "recipient_fingerprints")
+}
+
+func getFingerprints(recipients []*encryption.Recipient) []any {
+	fingerprints := make([]any, 0, len(recipients))
+	for _, recipient := range recipients {
+		fingerprints = append(fingerprints, recipient.GetFingerprint())
+	}
+
+	return fingerprints
+}
+
+// getConfiguredRecipients decodes the public keys from the configuration. It
+// returns false, if the keys are not known yet.
+func getConfiguredRecipients(rawConfig cty.Value) ([]*encryption.Recipient, bool, error) {
+	if rawConfig.IsNull() || !rawConfig.IsKnown() {
+		return nil, false, nil
+	}
+
+	publicKeysValue := rawConfig.GetAttr("public_keys")
+	if !publicKeysValue.IsWhollyKnown() {
+		return nil, false, nil
+	}
+
+	if publicKeysValue.IsNull() {
+		return nil, true, nil
+	}
+
+	publicKeys := make([]string, 0, publicKeysValue.LengthInt())
+	for i, publicKeyValue := range publicKeysValue.AsValueSlice() {
+		if publicKeyValue.IsNull() || !publicKeyValue.Type().Equals(cty.String) {
+			return nil, true, fmt.Errorf("expected type string on public key (idx %d), got %s", i, publicKeyValue.Type().FriendlyName())
+		}
+		publicKeys = append(publicKeys, publicKeyValue.AsString())
+	}
+
+	recipients, err := encryption.GetRecipients(publicKeys)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return recipients, true, nil
+}
+
+func equalValues(a, b []any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func resourceGPGEncryptedMessageUpdate(data *schema.ResourceData, meta any) error {
+	recipients, _, err := getConfiguredRecipients(data.GetRawConfig())
+	if err != nil {
+		return fmt.Errorf("getting recipients: %w", err)
+	}
+
+	if err := savePublicKeys(data, recipients, getProviderMeta(meta).withDefaultRecipients(recipients)); err != nil {
+		return fmt.Errorf("saving public keys: %w", err)
+	}
+
+	return resourceGPGEncryptedMessageRead(data, nil)
+}
@@ -0,0 +1,63 @@
+package opengpg_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// releasedProviderVersion is the last released version of the provider, of
+// which the states have schema version 0.
+const releasedProviderVersion = "0.2.0"
+
+func TestGPGEncryptedMessageUpgradeFromReleasedProvider(t *testing.T) {
+	t.Parallel()
+
+	var releasedResult string
+
+	resource.UnitTest(t, resource.TestCase{
+		Steps: []resource.TestStep{
+			{
+				ExternalProviders: map[string]resource.ExternalProvider{
+					"opengpg": {
+						Source:            "coopnorge/opengpg",
+						VersionConstraint: releasedProviderVersion,
+					},
+				},
+				Config: rsaConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.0", "4f54663daabdbaff"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+						releasedResult = value
+
+						return nil
+					}),
+				),
+			},
+			{
+				// Replacing key IDs in state with fingerprints is planned as an update.
+				ProviderFactories:  providerFactories,
+				Config:             rsaConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// The update keeps the message, re-creation would encrypt it again.
+				ProviderFactories: providerFactories,
+				Config:            rsaConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.0", "40b59cc2ed3da2213fd0aa5c4f54663daabdbaff"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.0", "40b59cc2ed3da2213fd0aa5c4f54663daabdbaff"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+						if value != releasedResult {
+							return fmt.Errorf("message was encrypted again, instead of updating its state")
+						}
+
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
@@ -1,11 +1,25 @@
 package opengpg_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 
+	protonpgp "github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/coopnorge/terraform-provider-opengpg/opengpg"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const rsaConfig = `
@@ -127,6 +141,31 @@ EOF
 
 `
 
+var signedConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted and signed message."
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+  signing_key            = var.opengpg_signing_key_rsa
+  signing_key_passphrase = "correct horse battery staple"
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to the signing key", rsa3072PublicKey) +
+	variableConfig("opengpg_signing_key_rsa", "A private-key of type RSA 3072, protected by a passphrase", rsa3072PrivateKey)
+
+var signedWithoutPassphraseConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted and signed message."
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+  signing_key = var.opengpg_signing_key_rsa
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to the signing key", rsa3072PublicKey) +
+	variableConfig("opengpg_signing_key_rsa", "A private-key of type RSA 3072, protected by a passphrase", rsa3072PrivateKey)
+
 const noPublicKeys = `
 resource "opengpg_encrypted_message" "example" {
   content     = "This is example of GPG encrypted message."
@@ -178,6 +217,28 @@ func TestGPGEncryptedMessageECC25519(t *testing.T) {
 	})
 }
 
+func TestGPGEncryptedMessageSigned(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: signedConfig,
+			},
+			{
+				Config:             signedConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				Config:      signedWithoutPassphraseConfig,
+				ExpectError: regexp.MustCompile(`getting signer: private key d8a1a867bacce331 is protected, but no passphrase was given`),
+			},
+		},
+	})
+}
+
 func TestGPGEncryptedMessageBadArguments(t *testing.T) {
 	t.Parallel()
 
@@ -197,3 +258,904 @@ func TestGPGEncryptedMessageBadArguments(t *testing.T) {
 func regexSpaceOrNewline(str string) string {
 	return strings.ReplaceAll(str, " ", "[\\ \\n]")
 }
+
+var writeOnlyConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content_wo         = "This is example of GPG encrypted message."
+  content_wo_version = %d
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const writeOnlyAndContentConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  content_wo  = "This is example of GPG encrypted message."
+  public_keys = ["not used"]
+}
+`
+
+func TestGPGEncryptedMessageWriteOnly(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(writeOnlyConfig, 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr("opengpg_encrypted_message.example", "content"),
+					resource.TestCheckNoResourceAttr("opengpg_encrypted_message.example", "content_wo"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "content_wo_version", "1"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result_base64", ""),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.0", "7b4273093fb8adb18ec2190bd8a1a867bacce331"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.#", "1"),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "recipient_fingerprints.0", "7b4273093fb8adb18ec2190bd8a1a867bacce331"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+						decrypter, err := encryption.GetDecrypter(rsa3072PrivateKey, "correct horse battery staple")
+						if err != nil {
+							return err
+						}
+						decryption, err := encryption.DecryptMessage(decrypter, nil, value)
+						if err != nil {
+							return err
+						}
+						if string(decryption.Content) != "This is example of GPG encrypted message." {
+							return fmt.Errorf("unexpected decrypted content %q", decryption.Content)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config:             fmt.Sprintf(writeOnlyConfig, 1),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				Config:             fmt.Sprintf(writeOnlyConfig, 2),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+var emptyContentConfig = `
+resource "opengpg_encrypted_message" "example" {
+  %s = ""
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+func TestGPGEncryptedMessageEmptyContent(t *testing.T) {
+	t.Parallel()
+
+	checkDecryptsToEmptyContent := func(value string) error {
+		decrypter, err := encryption.GetDecrypter(rsa3072PrivateKey, "correct horse battery staple")
+		if err != nil {
+			return err
+		}
+		decryption, err := encryption.DecryptMessage(decrypter, nil, value)
+		if err != nil {
+			return err
+		}
+		if len(decryption.Content) != 0 {
+			return fmt.Errorf("unexpected decrypted content %q", decryption.Content)
+		}
+		return nil
+	}
+
+	for _, key := range []string{"content", "content_wo"} {
+		t.Run(key, func(t *testing.T) {
+			t.Parallel()
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(emptyContentConfig, key),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsToEmptyContent),
+						),
+					},
+					{
+						Config:             fmt.Sprintf(emptyContentConfig, key),
+						PlanOnly:           true,
+						ExpectNonEmptyPlan: false,
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestGPGEncryptedMessageWriteOnlyBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      writeOnlyAndContentConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`"content": only one of .content,content_base64,content_wo,source. can be specified`)),
+			},
+		},
+	})
+}
+
+const passphraseConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content    = "This is example of GPG encrypted message."
+  passphrase = "correct horse battery staple"
+  s2k_mode   = "%s"
+}
+`
+
+var passphraseAndPublicKeyConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content    = "This is example of GPG encrypted message."
+  passphrase = "break glass"
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+var passphraseArgon2AndPublicKeyConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content    = "This is example of GPG encrypted message."
+  passphrase = "break glass"
+  s2k_mode   = "argon2"
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const noPublicKeysNorPassphrase = `
+resource "opengpg_encrypted_message" "example" {
+  content = "This is example of GPG encrypted message."
+}
+`
+
+const badS2KMode = `
+resource "opengpg_encrypted_message" "example" {
+  content    = "This is example of GPG encrypted message."
+  passphrase = "correct horse battery staple"
+  s2k_mode   = "simple"
+}
+`
+
+// checkDecryptsWithPassphrase checks, that the encrypted message can be decrypted with the passphrase.
+func checkDecryptsWithPassphrase(passphrase string) resource.CheckResourceAttrWithFunc {
+	return func(value string) error {
+		decrypter, err := protonpgp.PGP().Decryption().Password([]byte(passphrase)).New()
+		if err != nil {
+			return err
+		}
+		decrypted, err := decrypter.Decrypt([]byte(value), protonpgp.Armor)
+		if err != nil {
+			return err
+		}
+		if decrypted.String() != "This is example of GPG encrypted message." {
+			return fmt.Errorf("unexpected decrypted content %q", decrypted.String())
+		}
+		return nil
+	}
+}
+
+func TestGPGEncryptedMessagePassphrase(t *testing.T) {
+	t.Parallel()
+
+	for _, s2kMode := range encryption.S2KModes() {
+		t.Run(s2kMode, func(t *testing.T) {
+			t.Parallel()
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(passphraseConfig, s2kMode),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "passphrase", "c4bbcb1fbec99d65bf59d85c8cb62ee2db963f0fe106f483d9afa73bd4e39a8a"),
+							resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "s2k_mode", s2kMode),
+							resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.#", "0"),
+							resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPassphrase("correct horse battery staple")),
+						),
+					},
+					{
+						Config:             fmt.Sprintf(passphraseConfig, s2kMode),
+						PlanOnly:           true,
+						ExpectNonEmptyPlan: false,
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestGPGEncryptedMessagePassphraseAndPublicKey(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: passphraseAndPublicKeyConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.0", "7b4273093fb8adb18ec2190bd8a1a867bacce331"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPassphrase("break glass")),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+						decrypter, err := encryption.GetDecrypter(rsa3072PrivateKey, "correct horse battery staple")
+						if err != nil {
+							return err
+						}
+						_, err = encryption.DecryptMessage(decrypter, nil, value)
+						return err
+					}),
+				),
+			},
+			{
+				Config:             passphraseAndPublicKeyConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessagePassphraseBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      noPublicKeysNorPassphrase,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`one of .passphrase,public_keys. must be specified`)),
+			},
+			{
+				Config:      badS2KMode,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`expected s2k_mode to be one of ."iterated" "argon2"., got simple`)),
+			},
+			{
+				Config:      passphraseArgon2AndPublicKeyConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`S2K mode "argon2" requires AEAD encryption, which key d8a1a867bacce331 does not support`)),
+			},
+		},
+	})
+}
+
+var contentBase64Config = `
+resource "opengpg_encrypted_message" "example" {
+  content_base64 = "AP/+DQqACg=="
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const badContentBase64 = `
+resource "opengpg_encrypted_message" "example" {
+  content_base64 = "not base64"
+  public_keys    = ["not used"]
+}
+`
+
+func TestGPGEncryptedMessageContentBase64(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: contentBase64Config,
+				Check: resource.ComposeTestCheckFunc(
+					// SHA-256 of the decoded content.
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "content_base64", "b1a254ca94f7d98bded126d57537729fc0be6ca1df062152fa84cd8ea117356f"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+						decrypter, err := encryption.GetDecrypter(rsa3072PrivateKey, "correct horse battery staple")
+						if err != nil {
+							return err
+						}
+						decryption, err := encryption.DecryptMessage(decrypter, nil, value)
+						if err != nil {
+							return err
+						}
+						if !bytes.Equal(decryption.Content, []byte{0x00, 0xff, 0xfe, '\r', '\n', 0x80, '\n'}) {
+							return fmt.Errorf("unexpected decrypted content %x", decryption.Content)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config:             contentBase64Config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageContentBase64BadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      badContentBase64,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`expected "content_base64" to be a base64 string, got not base64`)),
+			},
+		},
+	})
+}
+
+var binaryBase64Config = `
+resource "opengpg_encrypted_message" "example" {
+  content       = "This is example of GPG encrypted message."
+  output_format = "binary_base64"
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const badOutputFormat = `
+resource "opengpg_encrypted_message" "example" {
+  content       = "This is example of GPG encrypted message."
+  output_format = "binary"
+  public_keys   = ["not used"]
+}
+`
+
+func TestGPGEncryptedMessageBinaryBase64(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: binaryBase64Config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result", ""),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result_base64", func(value string) error {
+						encrypted, err := base64.StdEncoding.DecodeString(value)
+						if err != nil {
+							return err
+						}
+						key, err := protonpgp.NewPrivateKeyFromArmored(rsa3072PrivateKey, []byte("correct horse battery staple"))
+						if err != nil {
+							return err
+						}
+						decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(key).New()
+						if err != nil {
+							return err
+						}
+						decrypted, err := decrypter.Decrypt(encrypted, protonpgp.Bytes)
+						if err != nil {
+							return err
+						}
+						if decrypted.String() != "This is example of GPG encrypted message." {
+							return fmt.Errorf("unexpected decrypted content %q", decrypted.String())
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config:             binaryBase64Config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageOutputFormatBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      badOutputFormat,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`expected output_format to be one of ."armored" "binary_base64"., got binary`)),
+			},
+		},
+	})
+}
+
+var profileConfig = `
+resource "opengpg_private_key" "example" {
+  user_ids {
+    email = "example@coop.no"
+  }
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content = "This is example of GPG encrypted message."
+  profile = %q
+  public_keys = [
+    var.opengpg_public_key_rsa,
+    opengpg_private_key.example.public_key_armored,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const badProfile = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  profile     = "rfc2440"
+  public_keys = ["not used"]
+}
+`
+
+func TestGPGEncryptedMessageProfile(t *testing.T) {
+	t.Parallel()
+
+	for _, profile := range encryption.Profiles() {
+		t.Run(profile, func(t *testing.T) {
+			t.Parallel()
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(profileConfig, profile),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "profile", profile),
+							resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", checkDecryptsWithPrivateKey),
+							checkDecryptsWithGeneratedKey("opengpg_encrypted_message.example", "opengpg_private_key.example"),
+						),
+					},
+					{
+						Config:             fmt.Sprintf(profileConfig, profile),
+						PlanOnly:           true,
+						ExpectNonEmptyPlan: false,
+					},
+				},
+			})
+		})
+	}
+}
+
+// checkDecryptsWithGeneratedKey checks, that the result of the message can be
+// decrypted with the private key of opengpg_private_key.
+func checkDecryptsWithGeneratedKey(messageName, keyName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		message, ok := s.RootModule().Resources[messageName]
+		if !ok {
+			return fmt.Errorf("not found: %s", messageName)
+		}
+		key, ok := s.RootModule().Resources[keyName]
+		if !ok {
+			return fmt.Errorf("not found: %s", keyName)
+		}
+
+		decrypter, err := encryption.GetDecrypter(key.Primary.Attributes["private_key_armored"], "")
+		if err != nil {
+			return err
+		}
+		decryption, err := encryption.DecryptMessage(decrypter, nil, message.Primary.Attributes["result"])
+		if err != nil {
+			return err
+		}
+		if string(decryption.Content) != "This is example of GPG encrypted message." {
+			return fmt.Errorf("unexpected decrypted content %q", decryption.Content)
+		}
+		return nil
+	}
+}
+
+func TestGPGEncryptedMessageProfileBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      badProfile,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`expected profile to be one of ."default" "rfc4880" "rfc9580"., got rfc2440`)),
+			},
+		},
+	})
+}
+
+var sourceConfig = `
+resource "opengpg_encrypted_message" "example" {
+  source = %q
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const sourceAndContentConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  source      = "message.txt"
+  public_keys = ["not used"]
+}
+`
+
+func TestGPGEncryptedMessageSource(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "message.txt")
+	if err := os.WriteFile(source, []byte("This is example of GPG encrypted message.\n"), 0o600); err != nil {
+		t.Fatalf("writing file to encrypt: %v", err)
+	}
+
+	config := fmt.Sprintf(sourceConfig, source)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "source_sha256", "a15f98c2d31171044d79045997c00915c036d44a59dd46384e2ae8463d096a43"),
+					resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+						decrypter, err := encryption.GetDecrypter(rsa3072PrivateKey, "correct horse battery staple")
+						if err != nil {
+							return err
+						}
+						decryption, err := encryption.DecryptMessage(decrypter, nil, value)
+						if err != nil {
+							return err
+						}
+						if string(decryption.Content) != "This is example of GPG encrypted message.\n" {
+							return fmt.Errorf("unexpected decrypted content %q", decryption.Content)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				PreConfig: func() {
+					if err := os.WriteFile(source, []byte("changed\n"), 0o600); err != nil {
+						t.Fatalf("changing encrypted file: %v", err)
+					}
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+var sourceCreatedDuringApplyConfig = `
+resource "opengpg_encrypted_message" "input" {
+  content     = %q
+  passphrase  = "correct horse battery staple"
+  output_path = %q
+  omit_result = true
+}
+
+resource "opengpg_encrypted_message" "example" {
+  source = opengpg_encrypted_message.input.output_path
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+
+  depends_on = [opengpg_encrypted_message.input]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+func TestGPGEncryptedMessageSourceCreatedDuringApply(t *testing.T) {
+	t.Parallel()
+
+	source := filepath.Join(t.TempDir(), "message.asc")
+
+	// Result must be the encrypted content of the file, as it was written during apply.
+	checkEncryptsSource := resource.TestCheckResourceAttrWith("opengpg_encrypted_message.example", "result", func(value string) error {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return err
+		}
+		decrypter, err := encryption.GetDecrypter(rsa3072PrivateKey, "correct horse battery staple")
+		if err != nil {
+			return err
+		}
+		decryption, err := encryption.DecryptMessage(decrypter, nil, value)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(decryption.Content, content) {
+			return fmt.Errorf("decrypted content %q does not match the source file %q", decryption.Content, content)
+		}
+		return nil
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				// The file does not exist during plan.
+				Config: fmt.Sprintf(sourceCreatedDuringApplyConfig, "first", source),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opengpg_encrypted_message.example", "source_sha256", "opengpg_encrypted_message.input", "output_sha256"),
+					checkEncryptsSource,
+				),
+			},
+			{
+				// The file is removed, and written again with other content during apply.
+				PreConfig: func() {
+					if err := os.Remove(source); err != nil {
+						t.Fatalf("removing source file: %v", err)
+					}
+				},
+				Config: fmt.Sprintf(sourceCreatedDuringApplyConfig, "second", source),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opengpg_encrypted_message.example", "source_sha256", "opengpg_encrypted_message.input", "output_sha256"),
+					checkEncryptsSource,
+				),
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageSourceBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      sourceAndContentConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`"content": only one of .content,content_base64,content_wo,source. can be specified`)),
+			},
+		},
+	})
+}
+
+var outputPathConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content         = "This is example of GPG encrypted message."
+  output_path     = %q
+  output_format   = %q
+  file_permission = "0640"
+  omit_result     = %t
+  public_keys = [
+    var.opengpg_public_key_rsa,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 3072, belonging to rsa3072PrivateKey", rsa3072PublicKey)
+
+const omitResultWithoutOutputPath = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  omit_result = true
+  public_keys = ["not used"]
+}
+`
+
+// checkOutputFile checks, that the file has the permission, and that it can be
+// decrypted with rsa3072PrivateKey.
+func checkOutputFile(path string, encoding int8) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		// The message is written to a temporary file, which is renamed to path.
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if len(entries) != 1 {
+			return fmt.Errorf("unexpected files in %q: %v", filepath.Dir(path), entries)
+		}
+		if info.Mode().Perm() != 0o640 {
+			return fmt.Errorf("unexpected file permission %o", info.Mode().Perm())
+		}
+		encrypted, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		key, err := protonpgp.NewPrivateKeyFromArmored(rsa3072PrivateKey, []byte("correct horse battery staple"))
+		if err != nil {
+			return err
+		}
+		decrypter, err := protonpgp.PGP().Decryption().DecryptionKey(key).New()
+		if err != nil {
+			return err
+		}
+		decrypted, err := decrypter.Decrypt(encrypted, encoding)
+		if err != nil {
+			return err
+		}
+		if decrypted.String() != "This is example of GPG encrypted message." {
+			return fmt.Errorf("unexpected decrypted content %q", decrypted.String())
+		}
+		return nil
+	}
+}
+
+// checkOutputFileResult checks, that the result in the attribute of the output
+// format holds the same message as the file in path.
+func checkOutputFileResult(path string, outputFormat string) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		encrypted, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if outputFormat == encryption.OutputFormatBinaryBase64 {
+			return resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result", ""),
+				resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result_base64", base64.StdEncoding.EncodeToString(encrypted)),
+			)(state)
+		}
+		return resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result", string(encrypted)),
+			resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result_base64", ""),
+		)(state)
+	}
+}
+
+func TestGPGEncryptedMessageOutputPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		outputFormat string
+		encoding     int8
+		omitResult   bool
+	}{
+		{name: "armored", outputFormat: encryption.OutputFormatArmored, encoding: protonpgp.Armor, omitResult: true},
+		{name: "binary_base64", outputFormat: encryption.OutputFormatBinaryBase64, encoding: protonpgp.Bytes, omitResult: true},
+		{name: "armored with result", outputFormat: encryption.OutputFormatArmored, encoding: protonpgp.Armor},
+		{name: "binary_base64 with result", outputFormat: encryption.OutputFormatBinaryBase64, encoding: protonpgp.Bytes},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			outputPath := filepath.Join(t.TempDir(), "secrets", "message.gpg")
+			config := fmt.Sprintf(outputPathConfig, outputPath, tc.outputFormat, tc.omitResult)
+
+			// The result, if kept, holds the same message as the file.
+			checkResult := resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result", ""),
+				resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "result_base64", ""),
+			)
+			if !tc.omitResult {
+				checkResult = checkOutputFileResult(outputPath, tc.outputFormat)
+			}
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config: config,
+						Check: resource.ComposeTestCheckFunc(
+							checkResult,
+							resource.TestCheckResourceAttrSet("opengpg_encrypted_message.example", "output_sha256"),
+							checkOutputFile(outputPath, tc.encoding),
+						),
+					},
+					{
+						Config:             config,
+						PlanOnly:           true,
+						ExpectNonEmptyPlan: false,
+					},
+					{
+						PreConfig: func() {
+							if err := os.WriteFile(outputPath, []byte("modified"), 0o640); err != nil {
+								t.Fatalf("modifying output file: %v", err)
+							}
+						},
+						Config:             config,
+						PlanOnly:           true,
+						ExpectNonEmptyPlan: true,
+					},
+					{
+						PreConfig: func() {
+							if err := os.Remove(outputPath); err != nil {
+								t.Fatalf("removing output file: %v", err)
+							}
+						},
+						Config:             config,
+						PlanOnly:           true,
+						ExpectNonEmptyPlan: true,
+					},
+					{
+						Config: config,
+						Check:  checkOutputFile(outputPath, tc.encoding),
+					},
+				},
+				CheckDestroy: func(*terraform.State) error {
+					if _, err := os.Stat(outputPath); !errors.Is(err, fs.ErrNotExist) {
+						return fmt.Errorf("output file was not removed: %v", err)
+					}
+					return nil
+				},
+			})
+		})
+	}
+}
+
+func TestGPGEncryptedMessageOutputPathBadArguments(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      omitResultWithoutOutputPath,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`"omit_result": all of .omit_result,output_path. must be specified`)),
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageStateUpgradeV0(t *testing.T) {
+	t.Parallel()
+
+	encryptedMessage := opengpg.Provider().ResourcesMap["opengpg_encrypted_message"]
+
+	stateV0 := map[string]any{
+		"id":          "37ce536006a221fdbef124ca9e34410294c55555a87d547bc8bcd20e76e46a3d",
+		"content":     "8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4",
+		"public_keys": []any{"d8a1a867bacce331"},
+	}
+
+	stateV1, err := encryptedMessage.StateUpgraders[0].Upgrade(context.Background(), stateV0, nil)
+	if err != nil {
+		t.Fatalf("upgrading state: %v", err)
+	}
+
+	// Key IDs can only be replaced with fingerprints, once the configured keys are read.
+	if publicKeys := stateV1["public_keys"]; !reflect.DeepEqual(publicKeys, []any{"d8a1a867bacce331"}) {
+		t.Errorf("unexpected public keys after upgrade: %v", publicKeys)
+	}
+
+	testCases := []struct {
+		name             string
+		oldValue         string
+		newValue         string
+		expectedSuppress bool
+	}{
+		{name: "v4 key ID", oldValue: "d8a1a867bacce331", newValue: "7b4273093fb8adb18ec2190bd8a1a867bacce331", expectedSuppress: true},
+		{name: "v6 key ID", oldValue: "cb186c4f0609a697", newValue: "cb186c4f0609a697e4d52dfa6c722b0c1f1e27c18a56708f6525ec27bad9acc9", expectedSuppress: true},
+		{name: "other key ID", oldValue: "4f54663daabdbaff", newValue: "7b4273093fb8adb18ec2190bd8a1a867bacce331", expectedSuppress: false},
+		{name: "other fingerprint", oldValue: "40b59cc2ed3da2213fd0aa5c4f54663daabdbaff", newValue: "7b4273093fb8adb18ec2190bd8a1a867bacce331", expectedSuppress: false},
+		{name: "new key", oldValue: "", newValue: "7b4273093fb8adb18ec2190bd8a1a867bacce331", expectedSuppress: false},
+	}
+
+	diffSuppressFunc := encryptedMessage.Schema["public_keys"].Elem.(*schema.Schema).DiffSuppressFunc
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if suppress := diffSuppressFunc("public_keys.0", tc.oldValue, tc.newValue, nil); suppress != tc.expectedSuppress {
+				t.Errorf("expected suppress %t, got %t", tc.expectedSuppress, suppress)
+			}
+		})
+	}
+}
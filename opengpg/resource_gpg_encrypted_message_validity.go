@@ -0,0 +1,398 @@
+package opengpg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	// keyValidityActionError fails the plan, when a public key expires within "min_key_validity".
+	keyValidityActionError = "error"
+	// keyValidityActionWarn only warns, when a public key expires within "min_key_validity".
+	keyValidityActionWarn = "warn"
+)
+
+// keyValidityActions returns all supported values of "min_key_validity_action".
+func keyValidityActions() []string {
+	return []string{keyValidityActionError, keyValidityActionWarn}
+}
+
+const (
+	// invalidKeyActionWarn only warns about existing messages, when a public key is expired or revoked.
+	invalidKeyActionWarn = "warn"
+	// invalidKeyActionError fails the plan of existing messages, when a public key is expired or revoked.
+	invalidKeyActionError = "error"
+)
+
+// invalidKeyActions returns all supported values of "invalid_key_action".
+func invalidKeyActions() []string {
+	return []string{invalidKeyActionWarn, invalidKeyActionError}
+}
+
+// recipientPolicy decides, how validity of public keys is checked. Resources
+// merge it from their own properties, and from "recipient_policy" of the provider.
+type recipientPolicy struct {
+	// minKeyValidity is the minimum time, for which keys must stay valid. Zero disables the check.
+	minKeyValidity time.Duration
+	// warnExpiring only warns about keys expiring within minKeyValidity.
+	warnExpiring bool
+	// failInvalid fails the plan of existing messages, which were encrypted with
+	// expired or revoked keys. They are only warned about otherwise, so plans
+	// of existing workspaces keep working, when keys expire.
+	failInvalid bool
+	// allowedFingerprints restricts recipients to keys with these fingerprints, unless it is empty.
+	allowedFingerprints []string
+	// requiredFingerprints must all be among the recipients.
+	requiredFingerprints []string
+}
+
+// getProviderRecipientPolicy returns "recipient_policy" of the provider.
+func getProviderRecipientPolicy(data *schema.ResourceData) (recipientPolicy, error) {
+	policy := recipientPolicy{}
+
+	minValidity, ok := data.Get("recipient_policy.0.min_key_validity").(string)
+	if !ok {
+		return policy, fmt.Errorf("data in property %q was not a string", "min_key_validity")
+	}
+
+	if minValidity != "" {
+		var err error
+
+		policy.minKeyValidity, err = time.ParseDuration(minValidity)
+		if err != nil {
+			return policy, fmt.Errorf("parsing property %q: %w", "min_key_validity", err)
+		}
+	}
+
+	policy.warnExpiring = data.Get("recipient_policy.0.min_key_validity_action") == keyValidityActionWarn
+	policy.failInvalid = data.Get("recipient_policy.0.invalid_key_action") == invalidKeyActionError
+
+	allowedFingerprints, err := getStringList(data, "recipient_policy.0.allowed_recipient_fingerprints")
+	if err != nil {
+		return policy, err
+	}
+
+	requiredFingerprints, err := getStringList(data, "recipient_policy.0.required_recipient_fingerprints")
+	if err != nil {
+		return policy, err
+	}
+
+	for _, fingerprint := range allowedFingerprints {
+		policy.allowedFingerprints = append(policy.allowedFingerprints, strings.ToLower(fingerprint))
+	}
+
+	for _, fingerprint := range requiredFingerprints {
+		policy.requiredFingerprints = append(policy.requiredFingerprints, strings.ToLower(fingerprint))
+	}
+
+	return policy, nil
+}
+
+// getRecipientPolicy returns the policy of the provider, overridden by the
+// properties set in the config of the resource.
+func getRecipientPolicy(rawConfig cty.Value, meta *providerMeta) (recipientPolicy, error) {
+	policy := meta.recipientPolicy
+
+	if minValidity, ok := getConfiguredString(rawConfig, "min_key_validity"); ok {
+		var err error
+
+		policy.minKeyValidity, err = time.ParseDuration(minValidity)
+		if err != nil {
+			return policy, fmt.Errorf("parsing property %q: %w", "min_key_validity", err)
+		}
+	}
+
+	if action, ok := getConfiguredString(rawConfig, "min_key_validity_action"); ok {
+		policy.warnExpiring = action == keyValidityActionWarn
+	}
+
+	if action, ok := getConfiguredString(rawConfig, "invalid_key_action"); ok {
+		policy.failInvalid = action == invalidKeyActionError
+	}
+
+	return policy, nil
+}
+
+// getConfiguredString returns the value of the property, if it is set in the config.
+func getConfiguredString(rawConfig cty.Value, key string) (string, bool) {
+	if rawConfig.IsNull() || !rawConfig.IsKnown() {
+		return "", false
+	}
+
+	value := rawConfig.GetAttr(key)
+	if !value.IsKnown() || value.IsNull() {
+		return "", false
+	}
+
+	return value.AsString(), true
+}
+
+// recipientValidityProblem describes a public key, which is expired or
+// revoked, or expires within "min_key_validity".
+type recipientValidityProblem struct {
+	// index of the key in "public_keys", or in "default_public_keys" of the provider.
+	index int
+	// isDefault is set for keys from "default_public_keys" of the provider.
+	isDefault bool
+	// invalid is set, when the key is expired or revoked.
+	invalid     bool
+	fingerprint string
+	message     string
+}
+
+// attributePath returns the path of the key in the config of the resource.
+// Default keys are configured in the provider instead.
+func (p recipientValidityProblem) attributePath() cty.Path {
+	if p.isDefault {
+		return nil
+	}
+
+	return cty.GetAttrPath("public_keys").IndexInt(p.index)
+}
+
+// checkRecipientsValidity returns problems of all public keys, which are
+// expired or revoked at the given point in time, or expire within minValidity
+// after it. Keys are referred to by name and their index in messages.
+func checkRecipientsValidity(name string, recipients []*encryption.Recipient, t time.Time, minValidity time.Duration) ([]recipientValidityProblem, error) {
+	problems := []recipientValidityProblem{}
+
+	for i, recipient := range recipients {
+		info, err := recipient.GetKeyInfo(t)
+		if err != nil {
+			return nil, fmt.Errorf("describing %s #%d: %w", name, i, err)
+		}
+
+		if info.IsRevoked {
+			problems = append(problems, recipientValidityProblem{
+				index:       i,
+				invalid:     true,
+				fingerprint: info.Fingerprint,
+				message:     fmt.Sprintf("%s #%d (%s) is revoked", name, i, describeKey(info)),
+			})
+
+			continue
+		}
+
+		if recipient.IsExpired(t) {
+			message := fmt.Sprintf("%s #%d (%s) is expired", name, i, describeKey(info))
+			if !info.ExpirationTime.IsZero() {
+				message = fmt.Sprintf("%s #%d (%s) expired at %s", name, i, describeKey(info), formatTime(info.ExpirationTime))
+			}
+
+			problems = append(problems, recipientValidityProblem{index: i, invalid: true, fingerprint: info.Fingerprint, message: message})
+
+			continue
+		}
+
+		if minValidity > 0 && !info.ExpirationTime.IsZero() && info.ExpirationTime.Before(t.Add(minValidity)) {
+			problems = append(problems, recipientValidityProblem{
+				index:       i,
+				fingerprint: info.Fingerprint,
+				message:     fmt.Sprintf("%s #%d (%s) expires at %s, which is within min_key_validity of %s", name, i, describeKey(info), formatTime(info.ExpirationTime), minValidity),
+			})
+		}
+	}
+
+	return problems, nil
+}
+
+// checkAllRecipientsValidity checks the configured public keys, and the
+// default public keys of the provider, which are not configured already.
+func checkAllRecipientsValidity(recipients []*encryption.Recipient, meta *providerMeta, minValidity time.Duration) ([]recipientValidityProblem, error) {
+	problems, err := checkRecipientsValidity("public key", recipients, meta.now(), minValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultProblems, err := checkRecipientsValidity("default public key", meta.defaultRecipients, meta.now(), minValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := map[string]bool{}
+	for _, recipient := range recipients {
+		configured[recipient.GetFingerprint()] = true
+	}
+
+	for _, problem := range defaultProblems {
+		if !configured[problem.fingerprint] {
+			problem.isDefault = true
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems, nil
+}
+
+// describeKey identifies the key by its key ID and email of the primary
+// identity, if it has one.
+func describeKey(info *encryption.KeyInfo) string {
+	if len(info.UserIDs) == 0 || info.UserIDs[0].Email == "" {
+		return fmt.Sprintf("key ID %s", info.KeyID)
+	}
+
+	return fmt.Sprintf("key ID %s, email %s", info.KeyID, info.UserIDs[0].Email)
+}
+
+// customizeDiffRecipientValidity fails the plan, when the message is about to
+// be encrypted with an expired public key, or with a key expiring within
+// "min_key_validity", unless it should only warn.
+func customizeDiffRecipientValidity(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+	// Existing messages are not encrypted again. Replacement is planned as a new resource.
+	if diff.Id() != "" {
+		return nil
+	}
+
+	recipients, known, err := getConfiguredRecipients(diff.GetRawConfig())
+	if err != nil || !known {
+		// Malformed keys are reported by Create, and unknown keys are checked once they are known.
+		return nil
+	}
+
+	config := getProviderMeta(meta)
+
+	policy, err := getRecipientPolicy(diff.GetRawConfig(), config)
+	if err != nil {
+		return err
+	}
+
+	problems, err := checkAllRecipientsValidity(recipients, config, policy.minKeyValidity)
+	if err != nil {
+		return err
+	}
+
+	messages := []string{}
+	for _, problem := range problems {
+		if problem.invalid || !policy.warnExpiring {
+			messages = append(messages, problem.message)
+		}
+	}
+
+	if len(messages) > 0 {
+		return fmt.Errorf("invalid public keys: %s", strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// customizeDiffInvalidRecipients fails the plan of existing messages, which
+// were encrypted with public keys that have since expired or were revoked,
+// when "invalid_key_action" is "error". Read does not get the config, so the configured
+// keys are checked during plan instead.
+//
+// Re-creation can not be planned instead, because Terraform plans the new
+// message of a replacement like any other new message, which is rejected by
+// customizeDiffRecipientValidity for the same keys.
+func customizeDiffInvalidRecipients(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+	if diff.Id() == "" {
+		return nil
+	}
+
+	config := getProviderMeta(meta)
+
+	policy, err := getRecipientPolicy(diff.GetRawConfig(), config)
+	if err != nil || !policy.failInvalid {
+		return err
+	}
+
+	recipients, known, err := getConfiguredRecipients(diff.GetRawConfig())
+	if err != nil || !known {
+		return nil
+	}
+
+	problems, err := checkAllRecipientsValidity(recipients, config, 0)
+	if err != nil {
+		return err
+	}
+
+	messages := []string{}
+	for _, problem := range problems {
+		if problem.invalid {
+			messages = append(messages, problem.message)
+		}
+	}
+
+	if len(messages) > 0 {
+		return fmt.Errorf("message must be encrypted again without invalid public keys: %s", strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// validateRecipientValidity warns about public keys expiring within
+// "min_key_validity", when "min_key_validity_action" is "warn", and about
+// expired or revoked keys, unless "invalid_key_action" is "error". Diffs can not
+// return warnings, so it is done during validation of the config.
+//
+// Validation does not get the provider configuration, so it is read with
+// providerMeta, once the provider is configured before the plan.
+func validateRecipientValidity(providerMetaFunc func() any) schema.ValidateRawResourceConfigFunc {
+	return func(_ context.Context, req schema.ValidateResourceConfigFuncRequest, resp *schema.ValidateResourceConfigFuncResponse) {
+		config := getProviderMeta(providerMetaFunc())
+
+		policy, err := getRecipientPolicy(req.RawConfig, config)
+		if err != nil {
+			// Malformed duration is reported by validation of the property.
+			return
+		}
+
+		if !policy.warnExpiring && policy.failInvalid {
+			return
+		}
+
+		recipients, known, err := getConfiguredRecipients(req.RawConfig)
+		if err != nil || !known {
+			return
+		}
+
+		problems, err := checkAllRecipientsValidity(recipients, config, policy.minKeyValidity)
+		if err != nil {
+			return
+		}
+
+		for _, problem := range problems {
+			switch {
+			case problem.invalid && !policy.failInvalid:
+				resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+					Severity:      diag.Warning,
+					Summary:       "Public key is no longer valid",
+					Detail:        problem.message + ", so the message should be encrypted again without it",
+					AttributePath: problem.attributePath(),
+				})
+			case !problem.invalid && policy.warnExpiring:
+				resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+					Severity:      diag.Warning,
+					Summary:       "Public key expires soon",
+					Detail:        problem.message,
+					AttributePath: problem.attributePath(),
+				})
+			}
+		}
+	}
+}
+
+// validateDuration validates, that the value is a non-negative duration like "720h".
+func validateDuration(val any, key string) ([]string, []error) {
+	value, ok := val.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %q to be string", key)}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("expected %q to be a duration like \"720h\", got %s", key, value)}
+	}
+
+	if duration < 0 {
+		return nil, []error{fmt.Errorf("expected %q to be a non-negative duration, got %s", key, value)}
+	}
+
+	return nil, nil
+}
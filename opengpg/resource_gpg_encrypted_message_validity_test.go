@@ -0,0 +1,266 @@
+package opengpg_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var expiredPublicKeyConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  public_keys = [
+    var.opengpg_public_key_ecc25519_expired,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_ecc25519_expired", "A public-key of type ECC 25519, which expired in 2024", ecc25519ExpiredPublicKey)
+
+// expiredSinceConfig encrypts the message at the given evaluation time, to
+// check it again after the key has expired.
+var expiredSinceConfig = `
+provider "opengpg" {
+  evaluation_time = %q
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  public_keys = [
+    var.opengpg_public_key_ecc25519_expired,
+  ]
+}
+` +
+	variableConfig("opengpg_public_key_ecc25519_expired", "A public-key of type ECC 25519, which expired in 2024", ecc25519ExpiredPublicKey)
+
+const expiringPublicKeyConfig = `
+resource "opengpg_private_key" "example" {
+  expiration_days = 30
+
+  user_ids {
+    email = "example@coop.no"
+  }
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content     = "This is example of GPG encrypted message."
+  public_keys = [
+    opengpg_private_key.example.public_key_armored,
+  ]
+  min_key_validity        = %q
+  min_key_validity_action = %q
+}
+`
+
+func TestGPGEncryptedMessageExpiredPublicKey(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      expiredPublicKeyConfig,
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`invalid public keys: public key #0 \(key ID 9edb3fd181a2ee9f, email foo@coop.no\) expired at 2024-09-25T09:23:42Z`)),
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageExpiredSince(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(expiredSinceConfig, "2024-09-25T00:00:00Z"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("opengpg_encrypted_message.example", "result"),
+				),
+			},
+			{
+				// The key expired in the meantime, which only warns by default, so
+				// plans of existing messages keep working.
+				Config:   fmt.Sprintf(expiredSinceConfig, "2025-01-01T00:00:00Z"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageMinKeyValidity(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		minKeyValidity       string
+		minKeyValidityAction string
+		expectError          *regexp.Regexp
+	}{
+		{name: "valid long enough", minKeyValidity: "24h", minKeyValidityAction: "error"},
+		{
+			name:                 "expires within",
+			minKeyValidity:       "2160h",
+			minKeyValidityAction: "error",
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`invalid public keys: public key #0 \(key ID [0-9a-f]{16}, email example@coop.no\) expires at [0-9TZ:-]+, which is within min_key_validity of 2160h0m0s`)),
+		},
+		{name: "expires within, warning only", minKeyValidity: "2160h", minKeyValidityAction: "warn"},
+		{
+			name:                 "malformed duration",
+			minKeyValidity:       "90 days",
+			minKeyValidityAction: "error",
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`expected "min_key_validity" to be a duration like "720h", got 90 days`)),
+		},
+		{
+			name:                 "negative duration",
+			minKeyValidity:       "-24h",
+			minKeyValidityAction: "error",
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`expected "min_key_validity" to be a non-negative duration, got -24h`)),
+		},
+		{
+			name:                 "unknown action",
+			minKeyValidity:       "24h",
+			minKeyValidityAction: "ignore",
+			expectError:          regexp.MustCompile(regexSpaceOrNewline(`expected min_key_validity_action to be one of ."error" "warn"., got ignore`)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.UnitTest(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				Steps: []resource.TestStep{
+					{
+						Config:      fmt.Sprintf(expiringPublicKeyConfig, tc.minKeyValidity, tc.minKeyValidityAction),
+						ExpectError: tc.expectError,
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "min_key_validity", tc.minKeyValidity),
+							resource.TestCheckResourceAttrSet("opengpg_encrypted_message.example", "result"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+var revokedPublicKeyConfig = `
+resource "opengpg_encrypted_message" "example" {
+  content            = "This is example of GPG encrypted message."
+  invalid_key_action = %q
+  public_keys = [
+    %s,
+  ]
+}
+
+variable "opengpg_public_key_revocable" {
+  description = "A public-key of type ECC 25519, before it was revoked"
+  default = <<EOF
+-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatN1xBYJKwYBBAHaRw8BAQdAYDcmQznlRhKKnM8a96ZrHYgZXUinzuFiOyer
+G7HFiF60I3Jldm9rZWQgY3VydmUga2V5IDxyZXZva2VkQGNvb3Aubm8+iJAEExYI
+ADgWIQS1eV4+Ar5+3odRPfMne+Ym00K2WAUCatN1xAIbAwULCQgHAgYVCgkICwIE
+FgIDAQIeAQIXgAAKCRAne+Ym00K2WI0uAP4k+X6SdJoYA46X6wfMsrLJdkFEetXQ
+r7OZ1SDMdmwIggD8Ckarg5w5jP9hQGLSGqqm7b2RQ6daFIqU+QcuQswenwG4OARq
+03XEEgorBgEEAZdVAQUBAQdAnl78BnRJ2psyS9p6zp+rxn4ed2mEPx6P2ZoNIH5n
+V0QDAQgHiHgEGBYIACAWIQS1eV4+Ar5+3odRPfMne+Ym00K2WAUCatN1xAIbDAAK
+CRAne+Ym00K2WN2GAP9IIIffSIn/nK9ek16ZroSH2zf5ctA/ARX177Ke5IfPjwEA
+87O1Gpq+Hed4efCql6/F0DyCS2LA17IcH62LN6uzUgA=
+=izQV
+-----END PGP PUBLIC KEY BLOCK-----
+EOF
+}
+
+variable "opengpg_public_key_revoked" {
+  description = "A public-key of type ECC 25519, which was revoked"
+  default = <<EOF
+-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatN1xBYJKwYBBAHaRw8BAQdAYDcmQznlRhKKnM8a96ZrHYgZXUinzuFiOyer
+G7HFiF6IeAQgFggAIBYhBLV5Xj4Cvn7eh1E98yd75ibTQrZYBQJq03XEAh0AAAoJ
+ECd75ibTQrZYGsgA/2rRXr4xToL8Sxoi8Hzl/irc/FVvjAbuusECEys+/3HVAP4g
+Evwx7xFYilxelgoKRF5TrUc17oQ+CqJTzKFOWm+PCLQjcmV2b2tlZCBjdXJ2ZSBr
+ZXkgPHJldm9rZWRAY29vcC5ubz6IkAQTFggAOBYhBLV5Xj4Cvn7eh1E98yd75ibT
+QrZYBQJq03XEAhsDBQsJCAcCBhUKCQgLAgQWAgMBAh4BAheAAAoJECd75ibTQrZY
+jS4A/iT5fpJ0mhgDjpfrB8yyssl2QUR61dCvs5nVIMx2bAiCAPwKRquDnDmM/2FA
+YtIaqqbtvZFDp1oUipT5By5CzB6fAbg4BGrTdcQSCisGAQQBl1UBBQEBB0CeXvwG
+dEnamzJL2nrOn6vGfh53aYQ/Ho/Zmg0gfmdXRAMBCAeIeAQYFggAIBYhBLV5Xj4C
+vn7eh1E98yd75ibTQrZYBQJq03XEAhsMAAoJECd75ibTQrZY3YYA/0ggh99Iif+c
+r16TXpmuhIfbN/ly0D8BFfXvsp7kh8+PAQDzs7Uamr4d53h58KqXr8XQPIJLYsDX
+shwfrYs3q7NSAA==
+=jHNt
+-----END PGP PUBLIC KEY BLOCK-----
+EOF
+}
+` +
+	variableConfig("opengpg_public_key_rsa", "A public-key of type RSA 4096, using the SHA256 hashing algorithm", rsa4096PublicKey)
+
+func TestGPGEncryptedMessageRevokedPublicKey(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(revokedPublicKeyConfig, "error", "var.opengpg_public_key_revoked"),
+				ExpectError: regexp.MustCompile(regexSpaceOrNewline(`invalid public keys: public key #0 \(key ID 277be626d342b658, email revoked@coop.no\) is revoked`)),
+			},
+		},
+	})
+}
+
+func TestGPGEncryptedMessageInvalidKeyAction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		resource.UnitTest(t, resource.TestCase{
+			ProviderFactories: providerFactories,
+			Steps: []resource.TestStep{
+				{
+					Config: fmt.Sprintf(revokedPublicKeyConfig, "error", "var.opengpg_public_key_revocable"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.0", "b5795e3e02be7ede87513df3277be626d342b658"),
+					),
+				},
+				{
+					// Fingerprint of the revoked key is the same, so only the plan can detect it.
+					Config:      fmt.Sprintf(revokedPublicKeyConfig, "error", "var.opengpg_public_key_revoked"),
+					PlanOnly:    true,
+					ExpectError: regexp.MustCompile(regexSpaceOrNewline(`message must be encrypted again without invalid public keys: public key #0 \(key ID 277be626d342b658, email revoked@coop.no\) is revoked`)),
+				},
+				{
+					// Without the revoked key, the message is encrypted again.
+					Config: fmt.Sprintf(revokedPublicKeyConfig, "error", "var.opengpg_public_key_rsa"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.#", "1"),
+						resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "public_keys.0", "40b59cc2ed3da2213fd0aa5c4f54663daabdbaff"),
+					),
+				},
+			},
+		})
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		t.Parallel()
+
+		resource.UnitTest(t, resource.TestCase{
+			ProviderFactories: providerFactories,
+			Steps: []resource.TestStep{
+				{
+					Config: fmt.Sprintf(revokedPublicKeyConfig, "warn", "var.opengpg_public_key_revocable"),
+				},
+				{
+					Config:             fmt.Sprintf(revokedPublicKeyConfig, "warn", "var.opengpg_public_key_revoked"),
+					PlanOnly:           true,
+					ExpectNonEmptyPlan: false,
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,160 @@
+package opengpg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// maxExpirationDays is the longest key lifetime, which fits into the 32-bit
+// lifetime of an OpenPGP key.
+const maxExpirationDays = 24855
+
+func resourceGPGPrivateKey() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGPGPrivateKeyCreate,
+		Read:   resourceStateOnlyRead,
+		Update: resourceStateOnlyUpdate,
+		Delete: resourceStateOnlyDelete,
+
+		CustomizeDiff: customizeDiffStateHash(hashedAttribute{key: "passphrase"}),
+
+		Schema: map[string]*schema.Schema{
+			"algorithm": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      encryption.KeyAlgorithmCurve25519,
+				ValidateFunc: validation.StringInSlice(encryption.KeyAlgorithms(), false),
+			},
+			"user_ids": {
+				Type:     schema.TypeList,
+				MinItems: 1,
+				Required: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+						"email": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+			"expiration_days": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntBetween(0, maxExpirationDays),
+			},
+			"passphrase": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"private_key_armored": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"public_key_armored": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"key_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func getUserIDs(data *schema.ResourceData) ([]encryption.UserID, error) {
+	userIDsAny, ok := data.Get("user_ids").([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected type %T on key %q, got %T", []any{}, "user_ids", data.Get("user_ids"))
+	}
+
+	userIDs := make([]encryption.UserID, 0, len(userIDsAny))
+	for i, v := range userIDsAny {
+		// Block without any attributes set is nil.
+		userID, ok := v.(map[string]any)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected type %T on user ID (idx %d), got %T", map[string]any{}, i, v)
+		}
+
+		name, _ := userID["name"].(string)
+		email, _ := userID["email"].(string)
+		if name == "" && email == "" {
+			return nil, fmt.Errorf("user ID (idx %d) must have either name or email", i)
+		}
+
+		userIDs = append(userIDs, encryption.UserID{Name: name, Email: email})
+	}
+
+	return userIDs, nil
+}
+
+func resourceGPGPrivateKeyCreate(data *schema.ResourceData, _ any) error {
+	userIDs, err := getUserIDs(data)
+	if err != nil {
+		return fmt.Errorf("getting user IDs: %w", err)
+	}
+
+	algorithm, ok := data.Get("algorithm").(string)
+	if !ok {
+		return fmt.Errorf("data in property %q was not a string", "algorithm")
+	}
+
+	expirationDays, ok := data.Get("expiration_days").(int)
+	if !ok {
+		return fmt.Errorf("data in property %q was not an int", "expiration_days")
+	}
+
+	// Only hash of the passphrase is planned.
+	passphrase, err := getConfigString(data, "passphrase")
+	if err != nil {
+		return err
+	}
+
+	key, err := encryption.GenerateKey(encryption.KeyOptions{
+		Algorithm:  algorithm,
+		UserIDs:    userIDs,
+		Lifetime:   time.Duration(expirationDays) * 24 * time.Hour,
+		Passphrase: passphrase,
+	})
+	if err != nil {
+		return fmt.Errorf("generating key: %w", err)
+	}
+
+	attributes := map[string]any{
+		"private_key_armored": key.PrivateKeyArmored,
+		"public_key_armored":  key.PublicKeyArmored,
+		"key_id":              key.KeyID,
+		"fingerprint":         key.Fingerprint,
+	}
+
+	for k, v := range attributes {
+		if err := data.Set(k, v); err != nil {
+			return fmt.Errorf("setting %q property: %w", k, err)
+		}
+	}
+
+	data.SetId(key.Fingerprint)
+
+	return nil
+}
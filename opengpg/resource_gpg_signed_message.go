@@ -0,0 +1,10 @@
+package opengpg
+
+import (
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceGPGSignedMessage() *schema.Resource {
+	return resourceGPGSignedContent(encryption.SignAndEncodeMessage)
+}
@@ -0,0 +1,92 @@
+package opengpg_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var signedMessageCurveConfig = `
+resource "opengpg_signed_message" "example" {
+  content     = "This is example of GPG signed message."
+  private_key = var.opengpg_private_key_curve
+}
+` +
+	variableConfig("opengpg_private_key_curve", "A private-key of type ECC 25519, not protected by a passphrase", curvePrivateKey)
+
+var signedMessageRSAConfig = `
+resource "opengpg_signed_message" "example" {
+  content     = "This is example of GPG signed message."
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "correct horse battery staple"
+}
+` +
+	variableConfig("opengpg_private_key_rsa", "A private-key of type RSA 3072, protected by a passphrase", rsa3072PrivateKey)
+
+var signedMessageWrongPassphraseConfig = `
+resource "opengpg_signed_message" "example" {
+  content     = "This is example of GPG signed message."
+  private_key = var.opengpg_private_key_rsa
+  passphrase  = "wrong passphrase"
+}
+` +
+	variableConfig("opengpg_private_key_rsa", "A private-key of type RSA 3072, protected by a passphrase", rsa3072PrivateKey)
+
+const signedMessageBadPrivateKey = `
+resource "opengpg_signed_message" "example" {
+  content     = "This is example of GPG signed message."
+  private_key = "not valid key"
+}
+`
+
+func TestGPGSignedMessageCurve(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: signedMessageCurveConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_signed_message.example", "key_id", "75fac23672df26de"),
+					resource.TestMatchResourceAttr("opengpg_signed_message.example", "result", regexp.MustCompile(`^-----BEGIN PGP MESSAGE-----`)),
+				),
+			},
+			{
+				Config:             signedMessageCurveConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				Config:      signedMessageBadPrivateKey,
+				ExpectError: regexp.MustCompile(`getting signer: decoding private key`),
+			},
+		},
+	})
+}
+
+func TestGPGSignedMessageRSA(t *testing.T) {
+	t.Parallel()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: signedMessageRSAConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_signed_message.example", "key_id", "d8a1a867bacce331"),
+				),
+			},
+			{
+				Config:             signedMessageRSAConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				Config:      signedMessageWrongPassphraseConfig,
+				ExpectError: regexp.MustCompile(`getting signer: unlocking private key`),
+			},
+		},
+	})
+}
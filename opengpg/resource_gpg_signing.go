@@ -0,0 +1,140 @@
+package opengpg
+
+import (
+	"fmt"
+
+	"github.com/coopnorge/terraform-provider-opengpg/encryption"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// signFunc signs the configured content with the signer, and returns the
+// ASCII-armored result.
+type signFunc func(data *schema.ResourceData, signer *encryption.Signer) (string, error)
+
+// resourceGPGSignedContent returns a resource, which signs its "content" with
+// the sign function. The result contains the content in plaintext.
+func resourceGPGSignedContent(sign func(*encryption.Signer, string) (string, error)) *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGPGSigningCreate(func(data *schema.ResourceData, signer *encryption.Signer) (string, error) {
+			content, ok := data.Get("content").(string)
+			if !ok {
+				return "", fmt.Errorf("data in property %q was not a string", "content")
+			}
+
+			signedMessage, err := sign(signer, content)
+			if err != nil {
+				return "", fmt.Errorf("signing message: %w", err)
+			}
+
+			return signedMessage, nil
+		}),
+		Read:   resourceStateOnlyRead,
+		Update: resourceStateOnlyUpdate,
+		Delete: resourceStateOnlyDelete,
+
+		CustomizeDiff: customizeDiffStateHash(hashedAttribute{key: "passphrase"}),
+
+		Schema: signingSchema(map[string]*schema.Schema{
+			"content": {
+				Type:      schema.TypeString,
+				Required:  true,
+				ForceNew:  true,
+				Sensitive: true,
+				StateFunc: sha256sum,
+			},
+			"result": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		}),
+	}
+}
+
+// signingSchema adds the properties of the signing key to the schema of a
+// signing resource.
+func signingSchema(properties map[string]*schema.Schema) map[string]*schema.Schema {
+	properties["private_key"] = &schema.Schema{
+		Type:      schema.TypeString,
+		Required:  true,
+		ForceNew:  true,
+		Sensitive: true,
+		// Private key should never end up in the state, so keep only a checksum of it.
+		StateFunc: sha256sum,
+	}
+	properties["passphrase"] = &schema.Schema{
+		Type:      schema.TypeString,
+		Optional:  true,
+		Computed:  true,
+		Sensitive: true,
+	}
+	properties["key_id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Computed: true,
+	}
+
+	return properties
+}
+
+// getSigner reads private key and its passphrase from the given properties.
+// Passphrase is read from the config, as only its hash is planned.
+func getSigner(data *schema.ResourceData, privateKeyKey string, passphraseKey string) (*encryption.Signer, error) {
+	privateKey, ok := data.Get(privateKeyKey).(string)
+	if !ok {
+		return nil, fmt.Errorf("data in property %q was not a string", privateKeyKey)
+	}
+
+	passphrase, err := getConfigString(data, passphraseKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.GetSigner(privateKey, passphrase)
+}
+
+// resourceGPGSigningCreate returns the Create of a signing resource, which
+// signs with the configured private key using the sign function.
+func resourceGPGSigningCreate(sign signFunc) schema.CreateFunc {
+	return func(data *schema.ResourceData, _ any) error {
+		signer, err := getSigner(data, "private_key", "passphrase")
+		if err != nil {
+			return fmt.Errorf("getting signer: %w", err)
+		}
+
+		if err := data.Set("key_id", signer.GetKeyID()); err != nil {
+			return fmt.Errorf("setting %q property: %w", "key_id", err)
+		}
+
+		result, err := sign(data, signer)
+		if err != nil {
+			return err
+		}
+
+		if err := data.Set("result", result); err != nil {
+			return fmt.Errorf("setting %q property: %w", "result", err)
+		}
+
+		// Calculate SHA-256 checksum of result for ID.
+		data.SetId(sha256sum(result))
+
+		return nil
+	}
+}
+
+// Resources, which only exist in state, have nothing to read or delete.
+func resourceStateOnlyRead(_ *schema.ResourceData, _ any) error {
+	return nil
+}
+
+func resourceStateOnlyDelete(d *schema.ResourceData, _ any) error {
+	d.SetId("")
+
+	return nil
+}
+
+// resourceStateOnlyUpdate has nothing to update either, as only plain SHA-256
+// checksums in state are migrated to HMAC in place, and everything else forces
+// re-creation.
+func resourceStateOnlyUpdate(_ *schema.ResourceData, _ any) error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package opengpg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// hashedAttribute is a secret property, of which only a hash is kept in state.
+// The hash is an HMAC-SHA-256, when "state_hash_key" is configured for the
+// provider, or a plain SHA-256 otherwise.
+//
+// StateFunc does not get the provider configuration, so such properties are
+// Optional and Computed instead, and the hash is planned by
+// customizeDiffStateHash.
+type hashedAttribute struct {
+	key string
+	// decode returns the bytes to hash, if they differ from the configured string.
+	decode func(string) ([]byte, error)
+}
+
+func (a hashedAttribute) hash(meta any, value string) (string, string, error) {
+	content := []byte(value)
+
+	if a.decode != nil {
+		decoded, err := a.decode(value)
+		if err != nil {
+			return "", "", fmt.Errorf("decoding property %q: %w", a.key, err)
+		}
+
+		content = decoded
+	}
+
+	return stateHash(meta, content), fmt.Sprintf("%x", sha256.Sum256(content)), nil
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(value)
+}
+
+// stateHash returns the hash of the content, which is kept in state.
+func stateHash(meta any, content []byte) string {
+	if config, ok := meta.(*providerMeta); ok && len(config.stateHashKey) > 0 {
+		mac := hmac.New(sha256.New, config.stateHashKey)
+		mac.Write(content)
+
+		return fmt.Sprintf("%x", mac.Sum(nil))
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// customizeDiffStateHash plans hashes of the configured values of the
+// properties, and forces re-creation of the resource, when any of them changes.
+//
+// States with a plain SHA-256 of an unchanged value are updated in place to
+// its HMAC-SHA-256, once "state_hash_key" is configured.
+func customizeDiffStateHash(attributes ...hashedAttribute) schema.CustomizeDiffFunc {
+	return func(_ context.Context, diff *schema.ResourceDiff, meta any) error {
+		for _, attribute := range attributes {
+			if err := planStateHash(diff, meta, attribute); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func planStateHash(diff *schema.ResourceDiff, meta any, attribute hashedAttribute) error {
+	rawConfig := diff.GetRawConfig()
+	if rawConfig.IsNull() || !rawConfig.IsKnown() {
+		return nil
+	}
+
+	oldValue, _ := diff.GetChange(attribute.key)
+
+	oldHash, ok := oldValue.(string)
+	if !ok {
+		return fmt.Errorf("data in property %q was not a string", attribute.key)
+	}
+
+	value := rawConfig.GetAttr(attribute.key)
+
+	if !value.IsKnown() {
+		if err := diff.SetNewComputed(attribute.key); err != nil {
+			return fmt.Errorf("setting %q property: %w", attribute.key, err)
+		}
+
+		return forceNewIfExists(diff, attribute.key)
+	}
+
+	// Optional and Computed property keeps its value in state, when it is removed from config.
+	if value.IsNull() {
+		if oldHash == "" {
+			return nil
+		}
+
+		if err := diff.SetNew(attribute.key, ""); err != nil {
+			return fmt.Errorf("setting %q property: %w", attribute.key, err)
+		}
+
+		return forceNewIfExists(diff, attribute.key)
+	}
+
+	newHash, plainHash, err := attribute.hash(meta, value.AsString())
+	if err != nil {
+		// Malformed values fail validation anyway.
+		return nil
+	}
+
+	if oldHash == newHash {
+		return diff.Clear(attribute.key)
+	}
+
+	if err := diff.SetNew(attribute.key, newHash); err != nil {
+		return fmt.Errorf("setting %q property: %w", attribute.key, err)
+	}
+
+	// Only the hash of the same value changes, so there is nothing to re-create.
+	if oldHash == plainHash {
+		return nil
+	}
+
+	return forceNewIfExists(diff, attribute.key)
+}
+
+func forceNewIfExists(diff *schema.ResourceDiff, key string) error {
+	if diff.Id() == "" {
+		return nil
+	}
+
+	return diff.ForceNew(key)
+}
+
+// getConfigString returns the value of the property from the config. It is
+// used for properties, of which the planned value is only a hash.
+func getConfigString(data *schema.ResourceData, key string) (string, error) {
+	value, _, err := lookupConfigString(data, key)
+
+	return value, err
+}
+
+// lookupConfigString returns the value of the property from the config, and
+// whether it is set. Unlike GetOk, an empty string is set.
+func lookupConfigString(data *schema.ResourceData, key string) (string, bool, error) {
+	value, diags := data.GetRawConfigAt(cty.GetAttrPath(key))
+	if diags.HasError() {
+		return "", false, fmt.Errorf("reading property %q from config: %v", key, diags)
+	}
+
+	if value.IsNull() {
+		return "", false, nil
+	}
+
+	if !value.IsKnown() || !value.Type().Equals(cty.String) {
+		return "", false, fmt.Errorf("data in property %q was not a string", key)
+	}
+
+	return value.AsString(), true, nil
+}
@@ -0,0 +1,118 @@
+package opengpg_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const stateHashKey = "correct horse battery staple"
+
+const stateHashConfig = `
+provider "opengpg" {
+  %s
+}
+
+resource "opengpg_encrypted_message" "example" {
+  content    = %q
+  passphrase = "correct horse battery staple"
+}
+
+resource "opengpg_detached_signature" "example" {
+  content     = %[2]q
+  private_key = var.opengpg_private_key
+  passphrase  = "correct horse battery staple"
+}
+
+variable "opengpg_private_key" {
+  default = <<EOF
+` + rsa3072PrivateKey + `
+EOF
+}
+`
+
+func hmacSHA256(key string, content string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(content))
+
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+// checkResourceID compares ID of the resource with the ID saved by the previous call.
+func checkResourceID(name string, id *string, same bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %q not found", name)
+		}
+
+		previousID := *id
+		*id = rs.Primary.ID
+
+		if previousID == "" {
+			return nil
+		}
+
+		if same && previousID != rs.Primary.ID {
+			return fmt.Errorf("expected resource %q to be updated in place, but it was re-created", name)
+		}
+		if !same && previousID == rs.Primary.ID {
+			return fmt.Errorf("expected resource %q to be re-created", name)
+		}
+
+		return nil
+	}
+}
+
+func TestStateHashKey(t *testing.T) {
+	t.Parallel()
+
+	var encryptedMessageID, detachedSignatureID string
+
+	withKey := fmt.Sprintf("state_hash_key = %q", stateHashKey)
+	content := "This is example of GPG encrypted message."
+	changedContent := "This is another example of GPG encrypted message."
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(stateHashConfig, "", content),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "content", fmt.Sprintf("%x", sha256.Sum256([]byte(content)))),
+					checkResourceID("opengpg_encrypted_message.example", &encryptedMessageID, true),
+					checkResourceID("opengpg_detached_signature.example", &detachedSignatureID, true),
+				),
+			},
+			{
+				// Existing checksums are replaced with HMAC without re-creation.
+				Config: fmt.Sprintf(stateHashConfig, withKey, content),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "content", hmacSHA256(stateHashKey, content)),
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "passphrase", hmacSHA256(stateHashKey, "correct horse battery staple")),
+					resource.TestCheckResourceAttr("opengpg_detached_signature.example", "content", hmacSHA256(stateHashKey, content)),
+					resource.TestCheckResourceAttr("opengpg_detached_signature.example", "passphrase", hmacSHA256(stateHashKey, "correct horse battery staple")),
+					checkResourceID("opengpg_encrypted_message.example", &encryptedMessageID, true),
+					checkResourceID("opengpg_detached_signature.example", &detachedSignatureID, true),
+				),
+			},
+			{
+				Config:             fmt.Sprintf(stateHashConfig, withKey, content),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			},
+			{
+				Config: fmt.Sprintf(stateHashConfig, withKey, changedContent),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opengpg_encrypted_message.example", "content", hmacSHA256(stateHashKey, changedContent)),
+					checkResourceID("opengpg_encrypted_message.example", &encryptedMessageID, false),
+					checkResourceID("opengpg_detached_signature.example", &detachedSignatureID, false),
+				),
+			},
+		},
+	})
+}